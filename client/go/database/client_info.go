@@ -0,0 +1,69 @@
+package database
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// Environment variables that identify the job in common CI systems, in order of preference.
+var ciJobEnvVars = []string{
+	"GITHUB_RUN_ID", // GitHub Actions
+	"CI_JOB_ID",     // GitLab
+	"BUILDKITE_JOB_ID",
+	"CIRCLE_WORKFLOW_JOB_ID",
+	"BUILD_TAG", // Jenkins
+	"BUILD_ID",
+}
+
+// Returns what we can find out about ourselves automatically, which tells
+// the server who's asking for the database.
+func defaultClientInfo() *pb.ClientInfo {
+	ci := &pb.ClientInfo{
+		Package: callerPackage(),
+		Pid:     int32(os.Getpid()),
+	}
+	ci.Hostname, _ = os.Hostname()
+	for _, v := range ciJobEnvVars {
+		if id := os.Getenv(v); id != "" {
+			ci.CiJobId = id
+			break
+		}
+	}
+	return ci
+}
+
+// Returns the import path of the first package up the stack that is not
+// this one, which is normally the test package that wants a database.
+func callerPackage() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	f, _ := frames.Next() // this function
+	self := packageOf(f.Function)
+	for {
+		f, more := frames.Next()
+		if pkg := packageOf(f.Function); pkg != self && pkg != "" {
+			return pkg
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// Returns the package path of a fully qualified function name, like
+// "github.com/foo/bar.(*T).Method".
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if slash < 0 {
+		slash = 0
+	}
+	dot := strings.Index(function[slash:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+dot]
+}
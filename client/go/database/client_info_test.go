@@ -0,0 +1,41 @@
+package database
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDefaultClientInfo(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "1234")
+	ci := defaultClientInfo()
+	if got, want := ci.Pid, int32(os.Getpid()); got != want {
+		t.Errorf("Got pid %v, want %v", got, want)
+	}
+	if ci.Hostname == "" {
+		t.Error("Got empty hostname, want hostname")
+	}
+	if got, want := ci.CiJobId, "1234"; got != want {
+		t.Errorf("Got CI job ID %q, want %q", got, want)
+	}
+	// We're called from the testing package, since this test is in the same package.
+	if got, want := ci.Package, "testing"; got != want {
+		t.Errorf("Got package %q, want %q", got, want)
+	}
+}
+
+func TestPackageOf(t *testing.T) {
+	for _, tc := range []struct {
+		function string
+		want     string
+	}{
+		{"github.com/foo/bar.Func", "github.com/foo/bar"},
+		{"github.com/foo/bar.(*T).Method", "github.com/foo/bar"},
+		{"github.com/foo.bar/baz.Func.func1", "github.com/foo.bar/baz"},
+		{"main.main", "main"},
+		{"", ""},
+	} {
+		if got := packageOf(tc.function); got != tc.want {
+			t.Errorf("packageOf(%q) = %q, want %q", tc.function, got, tc.want)
+		}
+	}
+}
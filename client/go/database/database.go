@@ -9,18 +9,79 @@ package database
 
 import (
 	"context"
+	"io"
 	"os"
+	"path/filepath"
+	"testing"
+	"time"
 
 	"github.com/golang/glog"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/karagog/db-provider/server/lease"
 	pb "github.com/karagog/db-provider/server/proto"
 )
 
+// Option customizes the database instance you request.
+type Option func(*pb.GetDatabaseInstanceRequest)
+
+// WithPool requests a database from the named pool, rather than the default one.
+func WithPool(name string) Option {
+	return func(req *pb.GetDatabaseInstanceRequest) { req.Pool = name }
+}
+
+// WithLeaseDuration asks for a lease that lasts the given time, up to the server's maximum.
+// The lease is lost after that, which aborts the test program.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(req *pb.GetDatabaseInstanceRequest) { req.LeaseDuration = durationpb.New(d) }
+}
+
+// WithMaxWait gives up if no database is available within the given time, up to
+// the server's maximum, rather than blocking indefinitely. New() panics with a
+// *lease.WaitTimeoutError in that case.
+func WithMaxWait(d time.Duration) Option {
+	return func(req *pb.GetDatabaseInstanceRequest) { req.MaxWait = durationpb.New(d) }
+}
+
+// WithSchema gets a database that is pre-loaded with the schema, which is
+// created by the given SQL statements (e.g. all of your migrations).
+// The server builds the schema only once and clones it for every test
+// that asks for the same one, which is much faster than running the
+// statements in every test.
+func WithSchema(sql string) Option {
+	return func(req *pb.GetDatabaseInstanceRequest) { req.Schema = &pb.Schema{Sql: sql} }
+}
+
+// WithTest tells the server which test is asking for the database, which helps
+// to track down a test that holds on to a database for too long.
+func WithTest(t testing.TB) Option {
+	return func(req *pb.GetDatabaseInstanceRequest) { req.ClientInfo.TestName = t.Name() }
+}
+
+// WithLabel attaches a free-form label to the lease, which the server reports
+// along with it.
+func WithLabel(key, value string) Option {
+	return func(req *pb.GetDatabaseInstanceRequest) {
+		if req.ClientInfo.Labels == nil {
+			req.ClientInfo.Labels = make(map[string]string)
+		}
+		req.ClientInfo.Labels[key] = value
+	}
+}
+
 type Instance struct {
 	// How to connect, or you can use the Connect/ConnectRoot() convenience methods.
 	Info *pb.ConnectionInfo
 
+	lease *lease.Lease
+	group *Group // the group to which the instance belongs, if any
+}
+
+// Group is a set of database instances that are leased and released together,
+// for example one for each of several services in a test.
+type Group struct {
+	Instances []*Instance
+
 	lease *lease.Lease
 }
 
@@ -28,36 +89,190 @@ type Instance struct {
 // This is the way most tests will get a database instance.
 //
 // You must Close() it when done to release your lock on the database.
-func NewFromEnv(ctx context.Context) *Instance {
+func NewFromEnv(ctx context.Context, opts ...Option) *Instance {
+	return New(ctx, addressFromEnv(), opts...)
+}
+
+// Gets a database instance from a provider service.
+// See also NewFromEnv().
+func New(ctx context.Context, databaseAddress string, opts ...Option) *Instance {
+	l, infos := acquire(ctx, databaseAddress, 1, opts)
+	return &Instance{
+		lease: l,
+		Info:  infos[0],
+	}
+}
+
+// Like NewFromEnv(), but gets a group of n instances.
+func NewGroupFromEnv(ctx context.Context, n int, opts ...Option) *Group {
+	return NewGroup(ctx, addressFromEnv(), n, opts...)
+}
+
+// Gets a group of n database instances from a provider service. They are all
+// granted at once, which avoids deadlocks between tests that each need several
+// databases. See also NewGroupFromEnv().
+//
+// You must Close() the group when done to release your lock on the databases.
+func NewGroup(ctx context.Context, databaseAddress string, n int, opts ...Option) *Group {
+	l, infos := acquire(ctx, databaseAddress, n, opts)
+	g := &Group{lease: l}
+	for _, info := range infos {
+		g.Instances = append(g.Instances, &Instance{Info: info, group: g})
+	}
+	return g
+}
+
+// Returns the address of the provider service from the environment.
+func addressFromEnv() string {
 	addr := os.Getenv("DB_INSTANCE_PROVIDER_ADDRESS")
 	if addr == "" {
 		panic("missing required envvar: DB_INSTANCE_PROVIDER_ADDRESS")
 	}
-	return New(ctx, addr)
+	return addr
 }
 
-// Gets a database instance from a provider service.
-// See also NewFromEnv().
-func New(ctx context.Context, databaseAddress string) *Instance {
-	// Connect to the test instance service to get a fresh mysql database.
-	l, err := lease.New(ctx, databaseAddress)
+// Leases count databases and returns how to connect to them.
+func acquire(ctx context.Context, databaseAddress string, count int, opts []Option) (*lease.Lease, []*pb.ConnectionInfo) {
+	req := &pb.GetDatabaseInstanceRequest{
+		ClientInfo: defaultClientInfo(),
+		Count:      int32(count),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	// Connect to the test instance service to get fresh mysql databases.
+	l, err := lease.New(ctx, databaseAddress, req)
 	if err != nil {
 		panic(err)
 	}
 	go l.Run()
 
-	// Block here indefinitely until an instance is ready. The client's Run() method
-	// maintains the lease on the instance until our Close() method is called.
-	i := l.ConnectionInfo()
-	glog.V(1).Infof("Lease acquired on %q", i.RootConn.Database)
-	return &Instance{
-		lease: l,
-		Info:  i,
+	// Block here indefinitely until the instances are ready. The client's Run() method
+	// maintains the lease on the instances until our Close() method is called.
+	infos, err := l.ConnectionInfos()
+	if err != nil {
+		l.Close()
+		panic(err)
+	}
+	for _, info := range infos {
+		glog.V(1).Infof("Lease acquired on %q", info.RootConn.Database)
+	}
+	return l, infos
+}
+
+// Checkpoint saves the state of the database under the name, so you can roll
+// back to it later with Rollback(), for example after an expensive setup that
+// several sub-tests share. If the instance belongs to a group, this
+// checkpoints the whole group.
+func (i *Instance) Checkpoint(ctx context.Context, name string) {
+	if i.group != nil {
+		i.group.Checkpoint(ctx, name)
+		return
+	}
+	if err := i.lease.Checkpoint(ctx, name); err != nil {
+		panic(err)
+	}
+}
+
+// Rollback restores the database to the named checkpoint. If the instance
+// belongs to a group, this rolls back the whole group.
+func (i *Instance) Rollback(ctx context.Context, name string) {
+	if i.group != nil {
+		i.group.Rollback(ctx, name)
+		return
+	}
+	if err := i.lease.Rollback(ctx, name); err != nil {
+		panic(err)
+	}
+}
+
+// Checkpoint saves the state of all the databases in the group under the name.
+func (g *Group) Checkpoint(ctx context.Context, name string) {
+	if err := g.lease.Checkpoint(ctx, name); err != nil {
+		panic(err)
+	}
+}
+
+// Rollback restores all the databases in the group to the named checkpoint.
+func (g *Group) Rollback(ctx context.Context, name string) {
+	if err := g.lease.Rollback(ctx, name); err != nil {
+		panic(err)
+	}
+}
+
+// Retain keeps the database for debugging after you Close() it, instead of
+// resetting it right away. The server keeps it for its retention period, and
+// how to connect to it is logged. For example:
+//
+//	defer func() {
+//		if t.Failed() {
+//			i.Retain()
+//		}
+//		i.Close()
+//	}()
+//
+// If the instance belongs to a group, this retains the whole group.
+func (i *Instance) Retain() {
+	if i.group != nil {
+		i.group.Retain()
+		return
+	}
+	if err := i.lease.Retain(); err != nil {
+		panic(err)
+	}
+}
+
+// Retain keeps all the databases in the group for debugging after you Close() it.
+func (g *Group) Retain() {
+	if err := g.lease.Retain(); err != nil {
+		panic(err)
+	}
+}
+
+// Dump writes a SQL dump of the database's schema and data, e.g. to save as a
+// CI artifact before you Close() it.
+func (i *Instance) Dump(ctx context.Context, w io.Writer) {
+	l := i.lease
+	if i.group != nil {
+		l = i.group.lease
+	}
+	if err := l.Dump(ctx, i.Info.RootConn.Database, w); err != nil {
+		panic(err)
+	}
+}
+
+// DumpToFile is like Dump(), but writes the dump to the file at the path,
+// creating its directory if necessary. For example:
+//
+//	defer func() {
+//		if t.Failed() {
+//			i.DumpToFile(ctx, filepath.Join(os.Getenv("ARTIFACTS_DIR"), t.Name()+".sql"))
+//		}
+//		i.Close()
+//	}()
+func (i *Instance) DumpToFile(ctx context.Context, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		panic(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	i.Dump(ctx, f)
+	if err := f.Close(); err != nil {
+		panic(err)
 	}
 }
 
 // Close releases the lock on the database instance when you're done using it.
+// If the instance belongs to a group, this releases the whole group.
 func (i *Instance) Close() {
+	if i.group != nil {
+		i.group.Close()
+		return
+	}
 	if i.Info == nil {
 		return
 	}
@@ -65,3 +280,17 @@ func (i *Instance) Close() {
 	i.lease.Close()
 	i.Info = nil
 }
+
+// Close releases the lock on all the database instances in the group when
+// you're done using them.
+func (g *Group) Close() {
+	if g.lease == nil {
+		return
+	}
+	for _, i := range g.Instances {
+		glog.V(1).Infof("Returning lease on %q", i.Info.RootConn.Database)
+		i.Info = nil
+	}
+	g.lease.Close()
+	g.lease = nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,11 +14,30 @@ import (
 	pb "github.com/karagog/db-provider/server/proto"
 	"github.com/karagog/db-provider/server/service"
 	"github.com/karagog/db-provider/server/service/runner"
+	"google.golang.org/grpc"
 )
 
+// Starts a fake in-memory service with the given pools, and returns its address.
+func startService(t *testing.T, pools ...*lessor.Lessor) string {
+	svc := service.New(simulated.NewClock(time.Now()), service.Options{})
+	svc.SetLessors(pools...)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	for _, l := range pools {
+		go l.Run(ctx)
+	}
+
+	r, err := runner.New(svc, "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go r.Run()
+	t.Cleanup(r.Stop)
+	return r.Address()
+}
+
 // Test that the client object successfully gets an instance from the service.
 func TestDatabase(t *testing.T) {
-	// Initialize a fake in-memory service.
 	provider := &fake.DatabaseProvider{
 		Info: pb.ConnectionInfo{
 			AppConn: &pb.ConnectionDetails{
@@ -28,24 +48,13 @@ func TestDatabase(t *testing.T) {
 			},
 		},
 	}
-	svc := service.New(simulated.NewClock(time.Now()))
-	l := lessor.New(provider, 1)
-	svc.SetLessor(l)
-	ctx := context.Background()
-	go l.Run(ctx)
-
-	r, err := runner.New(svc, "localhost:0")
-	if err != nil {
-		t.Fatal(err)
-	}
-	go r.Run()
-	defer r.Stop()
+	addr := startService(t, lessor.New(provider, lessor.Config{Size: 1}))
 
 	// Override the address so it uses our fake service.
-	os.Setenv("DB_INSTANCE_PROVIDER_ADDRESS", r.Address())
+	os.Setenv("DB_INSTANCE_PROVIDER_ADDRESS", addr)
 
 	// Get a database instance.
-	i := NewFromEnv(ctx)
+	i := NewFromEnv(context.Background())
 
 	// Make sure we got the connection info given to us by the server.
 	if diff := deep.Equal(i.Info, &provider.Info); diff != nil {
@@ -57,3 +66,149 @@ func TestDatabase(t *testing.T) {
 	// Close a second time, it should do nothing.
 	i.Close()
 }
+
+func TestDatabaseFromPool(t *testing.T) {
+	newProvider := func(user string) *fake.DatabaseProvider {
+		return &fake.DatabaseProvider{
+			Info: pb.ConnectionInfo{
+				AppConn:  &pb.ConnectionDetails{User: user},
+				RootConn: &pb.ConnectionDetails{User: "root"},
+			},
+		}
+	}
+	legacy := newProvider("legacy")
+	addr := startService(t,
+		lessor.New(newProvider("default"), lessor.Config{Size: 1}),
+		lessor.New(legacy, lessor.Config{Name: "legacy", Size: 1}))
+
+	i := New(context.Background(), addr, WithPool("legacy"))
+	defer i.Close()
+	if diff := deep.Equal(i.Info, &legacy.Info); diff != nil {
+		t.Fatalf("Got info from the wrong pool: %v", diff)
+	}
+}
+
+func TestDatabaseGroup(t *testing.T) {
+	provider := &fake.DatabaseProvider{
+		Info: pb.ConnectionInfo{RootConn: &pb.ConnectionDetails{User: "root"}},
+	}
+	l := lessor.New(provider, lessor.Config{Size: 3})
+	addr := startService(t, l)
+
+	g := NewGroup(context.Background(), addr, 2)
+	if got, want := len(g.Instances), 2; got != want {
+		t.Fatalf("Got %d instances, want %d", got, want)
+	}
+	if got, want := l.Stats().Leased, 2; got != want {
+		t.Fatalf("Got %d leased, want %d", got, want)
+	}
+
+	// Closing any of the instances releases the whole group.
+	g.Instances[1].Close()
+	for _, i := range g.Instances {
+		if i.Info != nil {
+			t.Fatal("Got info after closing, want nil")
+		}
+	}
+	g.Close() // does nothing the second time
+
+	// Now we can get the whole pool.
+	g = NewGroup(context.Background(), addr, 3)
+	defer g.Close()
+	if got, want := len(g.Instances), 3; got != want {
+		t.Fatalf("Got %d instances, want %d", got, want)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	provider := &fake.DatabaseProvider{
+		Info: pb.ConnectionInfo{RootConn: &pb.ConnectionDetails{User: "root"}},
+	}
+	addr := startService(t, lessor.New(provider, lessor.Config{Size: 2}))
+	ctx := context.Background()
+
+	g := NewGroup(ctx, addr, 2)
+	defer g.Close()
+
+	// Checkpointing one instance of the group checkpoints them all.
+	g.Instances[0].Checkpoint(ctx, "setup")
+	g.Instances[1].Rollback(ctx, "setup")
+	if got, want := len(provider.RollbackList), 2; got != want {
+		t.Fatalf("Got %d rollbacks, want %d", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Rolling back to a missing checkpoint didn't panic")
+		}
+	}()
+	g.Rollback(ctx, "bogus")
+}
+
+func TestRetain(t *testing.T) {
+	provider := &fake.DatabaseProvider{
+		Info: pb.ConnectionInfo{RootConn: &pb.ConnectionDetails{User: "root"}},
+	}
+	l := lessor.New(provider, lessor.Config{Size: 1})
+	addr := startService(t, l)
+
+	i := New(context.Background(), addr)
+	i.Retain()
+	i.Close()
+	if got, want := len(l.Retained()), 1; got != want {
+		t.Fatalf("Got %d retained databases, want %d", got, want)
+	}
+}
+
+func TestDumpToFile(t *testing.T) {
+	provider := &fake.DatabaseProvider{
+		Info: pb.ConnectionInfo{RootConn: &pb.ConnectionDetails{User: "root"}},
+		Dump: "CREATE TABLE foo (id INT);\n",
+	}
+	addr := startService(t, lessor.New(provider, lessor.Config{Size: 1}))
+	ctx := context.Background()
+
+	i := New(ctx, addr)
+	defer i.Close()
+	path := filepath.Join(t.TempDir(), "artifacts", "dump.sql")
+	i.DumpToFile(ctx, path)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), provider.Dump; got != want {
+		t.Fatalf("Got dump %q, want %q", got, want)
+	}
+}
+
+func TestDatabaseReportsClientInfo(t *testing.T) {
+	provider := &fake.DatabaseProvider{
+		Info: pb.ConnectionInfo{RootConn: &pb.ConnectionDetails{}},
+	}
+	addr := startService(t, lessor.New(provider, lessor.Config{Size: 1}))
+
+	i := New(context.Background(), addr, WithTest(t), WithLabel("team", "storage"))
+	defer i.Close()
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pb.NewAdminClient(conn).ListLeases(context.Background(), &pb.ListLeasesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(resp.Leases), 1; got != want {
+		t.Fatalf("Got %v leases, want %v", got, want)
+	}
+	ci := resp.Leases[0].ClientInfo
+	if got, want := ci.GetTestName(), t.Name(); got != want {
+		t.Errorf("Got test name %q, want %q", got, want)
+	}
+	if got, want := ci.GetPid(), int32(os.Getpid()); got != want {
+		t.Errorf("Got pid %v, want %v", got, want)
+	}
+	if diff := deep.Equal(ci.GetLabels(), map[string]string{"team": "storage"}); diff != nil {
+		t.Error(diff)
+	}
+}
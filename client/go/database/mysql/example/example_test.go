@@ -19,7 +19,7 @@ import (
 
 func TestMysqlDatabase(t *testing.T) {
 	// Instantiate a fresh new database.
-	i := database.NewFromEnv(context.Background())
+	i := database.NewFromEnv(context.Background(), database.WithTest(t))
 
 	// Connect as the administrative user in order to create tables.
 	rootDB := mysql.ConnectOrDie(i.Info.RootConn)
@@ -57,7 +57,7 @@ func TestMysqlDatabase(t *testing.T) {
 
 func TestUnprivilegedUserCannotCreateTable(t *testing.T) {
 	// Instantiate a new database and connect with the app user.
-	i := database.NewFromEnv(context.Background())
+	i := database.NewFromEnv(context.Background(), database.WithTest(t))
 	db := mysql.ConnectOrDie(i.Info.AppConn)
 
 	// Make sure the unprivileged user cannot create a table.
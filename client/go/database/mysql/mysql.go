@@ -3,6 +3,8 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"sort"
 
 	_ "github.com/go-sql-driver/mysql"
 	pb "github.com/karagog/db-provider/server/proto"
@@ -20,6 +22,21 @@ func ConnectOrDie(d *pb.ConnectionDetails) *sql.DB {
 // Connects to the database instance using the Mysql driver.
 func Connect(d *pb.ConnectionDetails) (*sql.DB, error) {
 	return sql.Open("mysql",
-		fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&multiStatements=true",
-			d.User, d.Password, d.Address, d.Port, d.Database))
+		fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&multiStatements=true%s",
+			d.User, d.Password, d.Address, d.Port, d.Database, sessionVariables(d)))
+}
+
+// Formats the session variables as DSN parameters, which the driver sets on
+// every new connection.
+func sessionVariables(d *pb.ConnectionDetails) string {
+	var names []string
+	for name := range d.SessionVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ret string
+	for _, name := range names {
+		ret += fmt.Sprintf("&%s=%s", name, url.QueryEscape(d.SessionVariables[name]))
+	}
+	return ret
 }
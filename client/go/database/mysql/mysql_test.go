@@ -62,3 +62,15 @@ func TestConnect(t *testing.T) {
 		t.Fatalf("Unable to ping database: %s", err)
 	}
 }
+
+func TestSessionVariables(t *testing.T) {
+	got := sessionVariables(&pb.ConnectionDetails{
+		SessionVariables: map[string]string{
+			"sql_mode":  "'ANSI'",
+			"time_zone": "'+00:00'",
+		},
+	})
+	if want := "&sql_mode=%27ANSI%27&time_zone=%27%2B00%3A00%27"; got != want {
+		t.Fatalf("Got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/karagog/db-provider/server/lessor"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+)
+
+// Config is the optional provider configuration file, which lets you host
+// several named pools of databases in addition to the default pool, give
+// leases users with other privileges than the root and app users, and spread
+// the databases over several MySQL servers.
+type Config struct {
+	Pools    []PoolConfig    `json:"pools"`
+	Profiles []Profile       `json:"profiles"`
+	Backends []BackendConfig `json:"backends"`
+}
+
+// BackendConfig configures a MySQL server over which the databases of every
+// pool are spread, in addition to the one given in the environment. It's
+// accessed with the same credentials.
+type BackendConfig struct {
+	// The name of the server in logs.
+	Name string `json:"name"`
+
+	// Where the provider and the clients connect to the server.
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+
+	// The server's share of the databases relative to the other servers.
+	// Zero means 1, like the server given in the environment.
+	Weight int `json:"weight"`
+}
+
+// Profile is a named set of privileges on the leased database. Every lease
+// gets its own user for each profile, in every pool.
+type Profile struct {
+	// The name by which clients select the connection.
+	Name string `json:"name"`
+
+	// The database privileges to grant, e.g. "SELECT" or "CREATE VIEW".
+	Privileges []string `json:"privileges"`
+}
+
+// PoolConfig configures a named pool of databases.
+type PoolConfig struct {
+	// The name by which clients select the pool.
+	Name string `json:"name"`
+
+	// How many databases to allocate for this pool.
+	Size int `json:"size"`
+
+	// How many databases the pool may grow to while clients are waiting.
+	// Zero means it doesn't grow.
+	MaxSize int `json:"max_size"`
+
+	// How many usable databases the pool needs, below which the service
+	// raises the alarm. Zero means the whole pool.
+	MinCapacity int `json:"min_capacity"`
+
+	// The default character set and collation of the databases.
+	Charset   string `json:"charset"`
+	Collation string `json:"collation"`
+
+	// The sql_mode that clients should use in their sessions.
+	SQLMode string `json:"sql_mode"`
+
+	// A file of SQL statements with which to seed every database. A relative
+	// path is resolved against the directory of the config file.
+	SchemaFile string `json:"schema_file"`
+
+	// How databases are cleaned up between leases: "drop" (the default),
+	// "truncate" or "restore".
+	ResetStrategy string `json:"reset_strategy"`
+}
+
+// The name of the MySQL server given in the environment, among the backends.
+const defaultBackend = "default"
+
+// Pool names become part of database names, so they must be valid identifiers,
+// and short enough to leave room for the rest of the name.
+var poolNameRE = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// The prefix and instance ID start every database name, and the name of the
+// lock that reserves them.
+var namingRE = regexp.MustCompile(`^[A-Za-z0-9]{1,16}$`)
+
+// MySQL limits the names of databases and locks to 64 characters.
+const maxNameLength = 64
+
+// The longest part of a database name after the pool's prefix: "db_" and an
+// index, with room for a million databases over the pool's lifetime, and
+// "_retained_" and the lease ID of a retained copy. The checkpoints'
+// "__ckpt_" and hash, and the templates' names, are shorter.
+const maxNameSuffix = len("db_999999") + len("_retained_") + 16
+
+// How much the random suffix adds to the names, if enabled.
+const randomSuffixLength = len("_") + 6
+
+// Profile names become part of user names, which MySQL limits to 32
+// characters, and "root" and "app" are the names of the fixed users.
+var profileNameRE = regexp.MustCompile(`^[A-Za-z0-9_]{1,12}$`)
+
+// The privileges that may be granted on a single database. Anything else is
+// either global, like SUPER, or would let a lease escape its database, like
+// GRANT OPTION.
+var databasePrivileges = map[string]bool{
+	"SELECT":                  true,
+	"INSERT":                  true,
+	"UPDATE":                  true,
+	"DELETE":                  true,
+	"CREATE":                  true,
+	"DROP":                    true,
+	"REFERENCES":              true,
+	"INDEX":                   true,
+	"ALTER":                   true,
+	"CREATE TEMPORARY TABLES": true,
+	"LOCK TABLES":             true,
+	"EXECUTE":                 true,
+	"CREATE VIEW":             true,
+	"SHOW VIEW":               true,
+	"CREATE ROUTINE":          true,
+	"ALTER ROUTINE":           true,
+	"EVENT":                   true,
+	"TRIGGER":                 true,
+}
+
+// LoadConfig reads and validates the config file at the given path.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	names := make(map[string]bool)
+	for i, p := range cfg.Pools {
+		if !poolNameRE.MatchString(p.Name) {
+			return nil, fmt.Errorf("invalid pool name %q", p.Name)
+		}
+		if p.Name == lessor.DefaultPool {
+			return nil, fmt.Errorf("pool name %q is reserved", p.Name)
+		}
+		if strings.HasPrefix(p.Name, "db_") {
+			// The default pool would mistake the pool's databases for its own.
+			return nil, fmt.Errorf("pool name %q may not start with db_", p.Name)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("duplicate pool name %q", p.Name)
+		}
+		names[p.Name] = true
+		if p.Size <= 0 {
+			return nil, fmt.Errorf("pool %q must have a positive size", p.Name)
+		}
+		if p.MaxSize != 0 && p.MaxSize < p.Size {
+			return nil, fmt.Errorf("pool %q must have a maximum size of at least its size", p.Name)
+		}
+		if p.MinCapacity < 0 || p.MinCapacity > p.Size {
+			return nil, fmt.Errorf("pool %q must have a minimum capacity between 0 and its size", p.Name)
+		}
+		if _, err := databaseprovider.ParseResetStrategy(p.ResetStrategy); err != nil {
+			return nil, fmt.Errorf("pool %q: %v", p.Name, err)
+		}
+		if p.SchemaFile != "" && !filepath.IsAbs(p.SchemaFile) {
+			cfg.Pools[i].SchemaFile = filepath.Join(filepath.Dir(path), p.SchemaFile)
+		}
+	}
+	if err := validateProfiles(cfg.Profiles); err != nil {
+		return nil, err
+	}
+	if err := validateBackends(cfg.Backends); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Checks that the backends are named uniquely and can be reached.
+func validateBackends(backends []BackendConfig) error {
+	names := map[string]bool{defaultBackend: true}
+	for _, b := range backends {
+		if b.Name == "" {
+			return fmt.Errorf("backend %s:%d must have a name", b.Address, b.Port)
+		}
+		if names[b.Name] {
+			return fmt.Errorf("duplicate backend name %q", b.Name)
+		}
+		names[b.Name] = true
+		if b.Address == "" || b.Port <= 0 || b.Port > 65535 {
+			return fmt.Errorf("backend %q must have an address and a valid port", b.Name)
+		}
+		if b.Weight < 0 {
+			return fmt.Errorf("backend %q must not have a negative weight", b.Name)
+		}
+	}
+	return nil
+}
+
+// Checks the prefix of the database names and the instance ID, which may be
+// empty. Neither may contain underscores, which separate them from the rest of
+// the name, so a pool never mistakes the databases of a pool with a longer
+// prefix for its own. Pools with the same prefix, e.g. a named pool "ci" and
+// the default pool of a provider with instance ID "ci", are kept apart by the
+// lock on each pool's prefix.
+//
+// It also checks that the longest names of the default pool's databases and
+// those of the named pools fit into MySQL's limit, which then holds for the
+// names of the locks, too.
+func validateNaming(prefix, instanceID string, randomSuffix bool, pools []PoolConfig) error {
+	if prefix != "" && !namingRE.MatchString(prefix) {
+		return fmt.Errorf("invalid database prefix %q", prefix)
+	}
+	if instanceID != "" && !namingRE.MatchString(instanceID) {
+		return fmt.Errorf("invalid instance ID %q", instanceID)
+	}
+	suffix := maxNameSuffix
+	if randomSuffix {
+		suffix += randomSuffixLength
+	}
+	// Like the lessors' prefixes, the named pools' include the pool name.
+	base := lessor.NamePrefix(prefix, instanceID)
+	if n := len(base) + suffix; n > maxNameLength {
+		return fmt.Errorf("database names may be %d characters long, more than MySQL allows; shorten the prefix or instance ID", n)
+	}
+	for _, p := range pools {
+		if n := len(base) + len(p.Name) + len("_") + suffix; n > maxNameLength {
+			return fmt.Errorf("pool %q: database names may be %d characters long, more than MySQL allows; shorten the pool name, prefix or instance ID", p.Name, n)
+		}
+	}
+	return nil
+}
+
+// Checks the profiles' names and privileges, and normalizes the privileges to
+// upper case, since they end up in GRANT statements.
+func validateProfiles(profiles []Profile) error {
+	names := make(map[string]bool)
+	for i, p := range profiles {
+		if !profileNameRE.MatchString(p.Name) {
+			return fmt.Errorf("invalid profile name %q", p.Name)
+		}
+		if p.Name == "root" || p.Name == "app" {
+			return fmt.Errorf("profile name %q is reserved", p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate profile name %q", p.Name)
+		}
+		names[p.Name] = true
+		if len(p.Privileges) == 0 {
+			return fmt.Errorf("profile %q must have privileges", p.Name)
+		}
+		for j, priv := range p.Privileges {
+			priv = strings.ToUpper(strings.Join(strings.Fields(priv), " "))
+			if !databasePrivileges[priv] {
+				return fmt.Errorf("profile %q: invalid privilege %q", p.Name, p.Privileges[j])
+			}
+			profiles[i].Privileges[j] = priv
+		}
+	}
+	return nil
+}
+
+// Settings returns the settings with which a MysqlProvider creates the pool's databases.
+func (p *PoolConfig) Settings() (PoolSettings, error) {
+	s := PoolSettings{
+		Charset:   p.Charset,
+		Collation: p.Collation,
+		SQLMode:   p.SQLMode,
+	}
+	if p.SchemaFile != "" {
+		b, err := os.ReadFile(p.SchemaFile)
+		if err != nil {
+			return s, err
+		}
+		s.Schema = string(b)
+	}
+	return s, nil
+}
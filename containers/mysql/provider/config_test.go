@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// Writes the files into a temporary directory and returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.json": `{
+			"pools": [
+				{"name": "legacy", "size": 2, "charset": "latin1", "sql_mode": "ANSI", "schema_file": "schema.sql"},
+				{"name": "modern", "size": 5, "max_size": 20, "min_capacity": 3, "reset_strategy": "truncate"}
+			],
+			"profiles": [
+				{"name": "reporting", "privileges": ["SELECT", "show  view"]},
+				{"name": "migrations", "privileges": ["ALTER", "CREATE", "DROP", "INDEX"]}
+			],
+			"backends": [
+				{"name": "second", "address": "10.0.0.2", "port": 3306, "weight": 2}
+			]
+		}`,
+		"schema.sql": "CREATE TABLE foo (id INT);",
+	})
+	cfg, err := LoadConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(cfg, &Config{
+		Pools: []PoolConfig{
+			{Name: "legacy", Size: 2, Charset: "latin1", SQLMode: "ANSI", SchemaFile: filepath.Join(dir, "schema.sql")},
+			{Name: "modern", Size: 5, MaxSize: 20, MinCapacity: 3, ResetStrategy: "truncate"},
+		},
+		Profiles: []Profile{
+			{Name: "reporting", Privileges: []string{"SELECT", "SHOW VIEW"}},
+			{Name: "migrations", Privileges: []string{"ALTER", "CREATE", "DROP", "INDEX"}},
+		},
+		Backends: []BackendConfig{
+			{Name: "second", Address: "10.0.0.2", Port: 3306, Weight: 2},
+		},
+	}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	s, err := cfg.Pools[0].Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(s, PoolSettings{
+		Charset: "latin1",
+		SQLMode: "ANSI",
+		Schema:  "CREATE TABLE foo (id INT);",
+	}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config string
+	}{
+		{"malformed", `{`},
+		{"invalid name", `{"pools": [{"name": "a-b", "size": 1}]}`},
+		{"long name", `{"pools": [{"name": "abcdefghijklmnopq", "size": 1}]}`},
+		{"reserved name", `{"pools": [{"name": "default", "size": 1}]}`},
+		{"name like a database", `{"pools": [{"name": "db_1", "size": 1}]}`},
+		{"duplicate name", `{"pools": [{"name": "a", "size": 1}, {"name": "a", "size": 1}]}`},
+		{"no size", `{"pools": [{"name": "a"}]}`},
+		{"max size below size", `{"pools": [{"name": "a", "size": 2, "max_size": 1}]}`},
+		{"min capacity above size", `{"pools": [{"name": "a", "size": 1, "min_capacity": 2}]}`},
+		{"invalid reset strategy", `{"pools": [{"name": "a", "size": 1, "reset_strategy": "vacuum"}]}`},
+		{"invalid profile name", `{"profiles": [{"name": "a-b", "privileges": ["SELECT"]}]}`},
+		{"long profile name", `{"profiles": [{"name": "abcdefghijklm", "privileges": ["SELECT"]}]}`},
+		{"reserved profile name", `{"profiles": [{"name": "app", "privileges": ["SELECT"]}]}`},
+		{"duplicate profile name", `{"profiles": [{"name": "a", "privileges": ["SELECT"]}, {"name": "a", "privileges": ["SELECT"]}]}`},
+		{"no privileges", `{"profiles": [{"name": "a"}]}`},
+		{"global privilege", `{"profiles": [{"name": "a", "privileges": ["SUPER"]}]}`},
+		{"grant option", `{"profiles": [{"name": "a", "privileges": ["SELECT", "GRANT OPTION"]}]}`},
+		{"injection", `{"profiles": [{"name": "a", "privileges": ["SELECT ON *.* TO x; --"]}]}`},
+		{"unnamed backend", `{"backends": [{"address": "a", "port": 1}]}`},
+		{"reserved backend name", `{"backends": [{"name": "default", "address": "a", "port": 1}]}`},
+		{"duplicate backend name", `{"backends": [{"name": "b", "address": "a", "port": 1}, {"name": "b", "address": "c", "port": 1}]}`},
+		{"no address", `{"backends": [{"name": "b", "port": 1}]}`},
+		{"invalid port", `{"backends": [{"name": "b", "address": "a", "port": 70000}]}`},
+		{"negative weight", `{"backends": [{"name": "b", "address": "a", "port": 1, "weight": -1}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"config.json": tc.config})
+			if _, err := LoadConfig(filepath.Join(dir, "config.json")); err == nil {
+				t.Fatal("Got nil error, want error")
+			}
+		})
+	}
+}
+
+func TestValidateNaming(t *testing.T) {
+	for _, tc := range []struct {
+		prefix, instanceID string
+		randomSuffix       bool
+		pool               string
+		ok                 bool
+	}{
+		{"", "", false, "", true},
+		{"team1", "", false, "", true},
+		{"team1", "ci", false, "", true},
+		{"", "ci", false, "", true},
+		{"team_1", "", false, "", false},
+		{"team1", "c-i", false, "", false},
+		{"abcdefghijklmnopq", "", false, "", false},
+		{"team1", "`; DROP DATABASE x", false, "", false},
+		// The names of retained copies must fit into 64 characters, e.g.
+		// "testserver_ci_legacy_db_999999_abcdef_retained_0123456789abcdef".
+		{"", "ci", true, "legacy", true},
+		{"", "ci", true, "legacy12", false},
+		{"abcdefghijklmnop", "abcdefghijklmnop", false, "", false},
+		{"abcdefghijklmnop", "abcdefghijk", false, "", true},
+		{"abcdefghijklmnop", "abcdefghijkl", false, "", false},
+	} {
+		var pools []PoolConfig
+		if tc.pool != "" {
+			pools = append(pools, PoolConfig{Name: tc.pool})
+		}
+		if err := validateNaming(tc.prefix, tc.instanceID, tc.randomSuffix, pools); (err == nil) != tc.ok {
+			t.Errorf("validateNaming(%q, %q, %t, %q) = %v, want ok=%v", tc.prefix, tc.instanceID, tc.randomSuffix, tc.pool, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpDatabase writes the database's tables and rows as SQL statements, like
+// mysqldump would, but without needing the mysqldump binary. It reads from a
+// consistent snapshot, so the lessee may keep using the database meanwhile.
+// Other objects, like views and triggers, are not dumped.
+func (m *MysqlProvider) DumpDatabase(ctx context.Context, database string, w io.Writer) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	tables, err := listTables(ctx, tx, database)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "-- Dump of database %s\n\n", database)
+	// The tables may reference each other, and we create them in arbitrary
+	// order. Like mysqldump, we insert zeros into AUTO_INCREMENT columns as they
+	// are, and set the sql_mode so the string escapes are always understood.
+	fmt.Fprintf(bw, "SET FOREIGN_KEY_CHECKS = 0;\nSET SESSION sql_mode = 'NO_AUTO_VALUE_ON_ZERO';\n\n")
+	for _, t := range tables {
+		if err := dumpTable(ctx, tx, database, t, bw); err != nil {
+			return fmt.Errorf("dumping table %s: %w", t, err)
+		}
+	}
+	fmt.Fprintf(bw, "SET FOREIGN_KEY_CHECKS = 1;\n\n-- Dump completed\n")
+	return bw.Flush()
+}
+
+// Writes the statements that create the table and insert its rows.
+func dumpTable(ctx context.Context, tx *sql.Tx, database, table string, w *bufio.Writer) error {
+	var name, create string
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf("SHOW CREATE TABLE %s.`%s`", database, table)).Scan(&name, &create); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "DROP TABLE IF EXISTS `%s`;\n%s;\n\n", table, create)
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s.`%s`", database, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	values := make([]sql.RawBytes, len(types))
+	dest := make([]interface{}, len(types))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	literals := make([]string, len(types))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			literals[i] = sqlLiteral(v, types[i].DatabaseTypeName())
+		}
+		fmt.Fprintf(w, "INSERT INTO `%s` VALUES (%s);\n", table, strings.Join(literals, ", "))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = w.WriteString("\n")
+	return err
+}
+
+// Returns the SQL literal for the column value, as the driver returned it in
+// text form, given the column's type.
+func sqlLiteral(v sql.RawBytes, typ string) string {
+	switch {
+	case v == nil:
+		return "NULL"
+	case isNumeric(typ):
+		return string(v)
+	case isBinary(typ) && len(v) > 0:
+		// The bytes may not be valid text, so spell them out.
+		return "0x" + hex.EncodeToString(v)
+	}
+	return quoteEscaped(string(v))
+}
+
+// Whether the values of the column type are written as bare numbers.
+func isNumeric(typ string) bool {
+	switch strings.TrimPrefix(typ, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "DECIMAL", "FLOAT", "DOUBLE", "YEAR":
+		return true
+	}
+	return false
+}
+
+// Whether the values of the column type are arbitrary bytes.
+func isBinary(typ string) bool {
+	switch typ {
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		return true
+	}
+	return false
+}
+
+// Quotes the string as a SQL string literal, escaping the characters that
+// MySQL treats specially.
+func quoteEscaped(s string) string {
+	return "'" + strings.NewReplacer(
+		`\`, `\\`,
+		"'", `\'`,
+		"\x00", `\0`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\x1a", `\Z`,
+	).Replace(s) + "'"
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSQLLiteral(t *testing.T) {
+	for _, tc := range []struct {
+		value sql.RawBytes
+		typ   string
+		want  string
+	}{
+		{nil, "VARCHAR", "NULL"},
+		{nil, "INT", "NULL"},
+		{sql.RawBytes("42"), "INT", "42"},
+		{sql.RawBytes("18446744073709551615"), "UNSIGNED BIGINT", "18446744073709551615"},
+		{sql.RawBytes("1.50"), "DECIMAL", "1.50"},
+		{sql.RawBytes(""), "VARCHAR", "''"},
+		{sql.RawBytes("it's"), "VARCHAR", `'it\'s'`},
+		{sql.RawBytes("a\\b\nc\x00"), "TEXT", `'a\\b\nc\0'`},
+		{sql.RawBytes("2021-03-26 00:00:00"), "DATETIME", "'2021-03-26 00:00:00'"},
+		{sql.RawBytes{0xff, 0x00}, "BLOB", "0xff00"},
+		{sql.RawBytes{}, "VARBINARY", "''"},
+	} {
+		if got := sqlLiteral(tc.value, tc.typ); got != tc.want {
+			t.Errorf("sqlLiteral(%q, %s): got %s, want %s", tc.value, tc.typ, got, tc.want)
+		}
+	}
+}
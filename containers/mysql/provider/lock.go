@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// How often to check that we still hold the lock, which also keeps its
+// connection from timing out while idle.
+const lockCheckInterval = time.Minute
+
+// errLockTaken means that another provider holds the lock.
+var errLockTaken = errors.New("another provider is using the database prefix")
+
+// prefixLock is a named MySQL lock that tells other providers that the prefix
+// of the database names is taken, since a provider drops the databases with
+// its prefix that it doesn't know about. The server releases the lock when
+// its connection closes, e.g. when the provider crashes.
+type prefixLock struct {
+	db   *sql.DB // const
+	name string  // const
+
+	mu   sync.Mutex
+	conn *sql.Conn // holds the lock, or nil if we don't hold it
+}
+
+// Returns the name of the lock that guards the prefix of the database names.
+func lockName(prefix string) string {
+	return "db-provider/" + prefix
+}
+
+// Returns the lock on the prefix, which isn't taken yet.
+func newPrefixLock(db *sql.DB, prefix string) *prefixLock {
+	return &prefixLock{db: db, name: lockName(prefix)}
+}
+
+// Takes the lock on the prefix, or fails if another provider holds it.
+func acquireLock(ctx context.Context, db *sql.DB, prefix string) (*prefixLock, error) {
+	l := newPrefixLock(db, prefix)
+	if err := l.ensure(ctx); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Takes the lock unless we hold it already, without waiting for it.
+func (l *prefixLock) ensure(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		return nil
+	}
+	return l.acquireLocked(ctx)
+}
+
+// Takes the lock on a new connection, without waiting for it.
+func (l *prefixLock) acquireLocked(ctx context.Context) error {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", l.name).Scan(&got); err != nil {
+		conn.Close()
+		return err
+	}
+	if got.Int64 != 1 {
+		conn.Close()
+		return fmt.Errorf("%w (lock %q is taken)", errLockTaken, l.name)
+	}
+	l.conn = conn
+	return nil
+}
+
+// Checks periodically that we still hold the lock until the context ends, and
+// takes it again if the connection was lost, e.g. when MySQL restarted. If
+// another provider took it in the meantime, we exit rather than both managing
+// the same databases; in particular we mustn't clean up the databases, which
+// are now the other provider's.
+func (l *prefixLock) keepAlive(ctx context.Context) {
+	t := time.NewTicker(lockCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		l.check(ctx)
+	}
+}
+
+func (l *prefixLock) check(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		var held sql.NullInt64
+		err := l.conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?) = CONNECTION_ID()", l.name).Scan(&held)
+		if err == nil && held.Int64 == 1 {
+			return
+		}
+		glog.Warningf("Lost lock %q (%v), taking it again", l.name, err)
+		l.conn.Close()
+		l.conn = nil
+	}
+	err := l.acquireLocked(ctx)
+	switch {
+	case errors.Is(err, errLockTaken):
+		glog.Fatalf("Lost lock %q to another provider: %s", l.name, err)
+	case err != nil:
+		glog.Errorf("Error taking lock %q: %s", l.name, err)
+	}
+}
+
+// Releases the lock, if we hold it.
+func (l *prefixLock) release(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return
+	}
+	if _, err := l.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.name); err != nil {
+		glog.Errorf("Error releasing lock %q: %s", l.name, err)
+	}
+	l.conn.Close()
+	l.conn = nil
+}
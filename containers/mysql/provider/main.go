@@ -9,15 +9,21 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/golang/glog"
-	"github.com/karagog/clock-go/simulated"
+	"github.com/karagog/clock-go/real"
 	"github.com/karagog/cloudutil-go/healthcheck"
-	"github.com/karagog/db-provider/client/go/database/mysql"
 	"github.com/karagog/db-provider/server/lessor"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/multi"
 	"github.com/karagog/db-provider/server/service"
+	"github.com/karagog/db-provider/server/service/gateway"
 	"github.com/karagog/db-provider/server/service/runner"
 )
 
@@ -38,7 +44,13 @@ func main() {
 	}
 
 	// Start up the server.
-	svc := service.New(simulated.NewClock(time.Now()))
+	svc := service.New(&real.Clock{}, service.Options{
+		MaxLeaseDuration:     getDurationEnv("PROVIDER_MAX_LEASE_DURATION"),
+		DefaultLeaseDuration: getDurationEnv("PROVIDER_DEFAULT_LEASE_DURATION"),
+		ExpiryWarning:        getDurationEnv("PROVIDER_LEASE_EXPIRY_WARNING"),
+		MaxWait:              getDurationEnv("PROVIDER_MAX_WAIT"),
+		HeartbeatTTL:         getDurationEnv("PROVIDER_HEARTBEAT_TTL"),
+	})
 	r, err := runner.New(svc, fmt.Sprintf(":%d", port))
 	if err != nil {
 		glog.Fatal(err)
@@ -47,67 +59,217 @@ func main() {
 	go r.Run()
 
 	// Register the health check, and notify that we're healthy immediately,
-	// because callers will block until the database is ready.
+	// because callers will block until the database is ready. The same port
+	// serves the service over HTTP for clients that don't have gRPC.
 	mux := http.NewServeMux()
 	healthcheck.Register(mux)
 	healthcheck.SetOK()
+	gateway.Register(mux, svc)
 	go func() {
 		if err := http.ListenAndServe(":80", mux); err != nil {
-			glog.Errorf("Error listening on healthcheck port: %s", err)
+			glog.Errorf("Error listening on HTTP port: %s", err)
 		}
 	}()
 
-	p, err := initContainer(context.Background(),
-		getEnvOrDie("MYSQL_ROOT_HOST"),
-		getConnectionParamsOrDie())
-	if err != nil {
+	// The config file is optional, and adds named pools to the default one.
+	cfg := &Config{}
+	if path := os.Getenv("PROVIDER_CONFIG"); path != "" {
+		if cfg, err = LoadConfig(path); err != nil {
+			glog.Fatal(err)
+		}
+	}
+
+	// The databases are spread over the server in the environment and the
+	// configured backends, of which at least one must be up to start. The
+	// others join once they're up.
+	prefix, instanceID := os.Getenv("PROVIDER_DB_PREFIX"), os.Getenv("PROVIDER_INSTANCE_ID")
+	randomSuffix := getBoolEnv("PROVIDER_RANDOM_SUFFIX")
+	if err := validateNaming(prefix, instanceID, randomSuffix, cfg.Pools); err != nil {
 		glog.Fatal(err)
 	}
+	params := getConnectionParamsOrDie()
+	backends := []BackendConfig{{Name: defaultBackend, Address: params.MysqlAddress, Port: params.MysqlPort}}
+	var servers []*server
+	for _, bc := range append(backends, cfg.Backends...) {
+		bp := *params
+		bp.MysqlAddress, bp.MysqlPort = bc.Address, bc.Port
+		s, err := newServer(bc, &bp)
+		if err != nil {
+			glog.Fatalf("Backend %q: %s", bc.Name, err)
+		}
+		servers = append(servers, s)
+	}
+	initCtx, cancel := context.WithTimeout(context.Background(), initTimeout)
+	var initWG sync.WaitGroup
+	for _, s := range servers {
+		initWG.Add(1)
+		go func(s *server) {
+			defer initWG.Done()
+			s.startErr = s.waitUp(initCtx)
+		}(s)
+	}
+	initWG.Wait()
+	cancel()
+	up := 0
+	for _, s := range servers {
+		if s.startErr != nil {
+			glog.Errorf("Backend %q is unreachable, starting without it: %s", s.config.Name, s.startErr)
+			continue
+		}
+		up++
+	}
+	if up == 0 {
+		glog.Fatal("No backend is reachable")
+	}
 	glog.Info("Database initialized, ready to serve requests...")
 
-	// Now that the database is initialized, update the service which tells
-	// clients that it's okay to request databases.
-	l := lessor.New(p, count)
-	svc.SetLessor(l)
-
-	// Block here indifinitely while the service runs.
-	l.Run(context.Background())
-}
+	// Each pool gets its own provider with the pool's settings, all sharing
+	// the same connection to the server and the same profiles.
+	base := lessor.Config{
+		Prefix:        prefix,
+		InstanceID:    instanceID,
+		RandomSuffix:  randomSuffix,
+		Retention:     getDurationEnv("PROVIDER_RETENTION"),
+		ResetAttempts: getIntEnv("PROVIDER_RESET_ATTEMPTS"),
+		ResetBackoff:  getDurationEnv("PROVIDER_RESET_BACKOFF"),
+		IdleTimeout:   getDurationEnv("PROVIDER_IDLE_TIMEOUT"),
+		VerifyResets:  getBoolEnv("PROVIDER_VERIFY"),
+	}
+	lc := base
+	lc.Size, lc.MaxSize = count, getIntEnv("PROVIDER_MAX_DB_INSTANCES")
+	lc.MinCapacity = getIntEnv("PROVIDER_MIN_CAPACITY")
+	lc.Journal = journalPath(lessor.DefaultPool)
+	if lc.ResetStrategy, err = databaseprovider.ParseResetStrategy(os.Getenv("PROVIDER_RESET_STRATEGY")); err != nil {
+		glog.Fatal(err)
+	}
+	var monitors []*multi.Provider
+	newLessor := func(settings PoolSettings, lc lessor.Config) *lessor.Lessor {
+		p := poolProvider(servers, settings, cfg.Profiles)
+		if m, ok := p.(*multi.Provider); ok {
+			monitors = append(monitors, m)
+		}
+		return lessor.New(p, lc)
+	}
+	lessors := []*lessor.Lessor{newLessor(PoolSettings{}, lc)}
+	for _, pc := range cfg.Pools {
+		settings, err := pc.Settings()
+		if err != nil {
+			glog.Fatalf("Pool %q: %s", pc.Name, err)
+		}
+		lc := base
+		lc.Name, lc.Size, lc.MaxSize, lc.MinCapacity = pc.Name, pc.Size, pc.MaxSize, pc.MinCapacity
+		lc.Journal = journalPath(pc.Name)
+		lc.ResetStrategy, _ = databaseprovider.ParseResetStrategy(pc.ResetStrategy) // validated by LoadConfig
+		lessors = append(lessors, newLessor(settings, lc))
+	}
 
-// initContainer initializes the docker container and returns a provider object.
-func initContainer(ctx context.Context, allowConnectionsFrom string, opt *MysqlConnParams) (*MysqlProvider, error) {
-	glog.Infof("Initializing mysql database container")
+	// Refuse to start if another provider uses the same names for the
+	// databases of any of its pools, since we'd drop each other's databases.
+	// The locks on the backends that are down are taken when they're up.
+	for _, s := range servers {
+		for _, l := range lessors {
+			if s.startErr != nil {
+				s.locks = append(s.locks, newPrefixLock(s.provider.DB, l.Prefix()))
+				continue
+			}
+			lock, err := acquireLock(context.Background(), s.provider.DB, l.Prefix())
+			if err != nil {
+				glog.Fatalf("Backend %q, pool %q: %s", s.config.Name, l.Name(), err)
+			}
+			s.locks = append(s.locks, lock)
+		}
+	}
 
-	newCtx, cancel := context.WithDeadline(ctx, time.Now().Add(3*time.Minute))
-	defer cancel()
+	// Now that the database is initialized, update the service which tells
+	// clients that it's okay to request databases.
+	svc.SetLessors(lessors...)
 
-	p := &MysqlProvider{Conn: *opt}
-	db, err := mysql.Connect(p.GetConnectionInfo("").RootConn)
-	if err != nil {
-		return nil, err
+	runCtx, stopLessors := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		for _, lock := range s.locks {
+			wg.Add(1)
+			go func(lock *prefixLock) {
+				defer wg.Done()
+				lock.keepAlive(runCtx)
+			}(lock)
+		}
+	}
+	for _, m := range monitors {
+		wg.Add(1)
+		go func(m *multi.Provider) {
+			defer wg.Done()
+			m.Monitor(runCtx, backendCheckInterval)
+		}(m)
+	}
+	for _, l := range lessors {
+		wg.Add(1)
+		go func(l *lessor.Lessor) {
+			defer wg.Done()
+			l.Run(runCtx)
+		}(l)
 	}
-	p.DB = db
 
-	err = runMysqlCmd(newCtx, db, fmt.Sprintf("CREATE USER IF NOT EXISTS '%s'@'%s' IDENTIFIED BY '%s'",
-		opt.User, allowConnectionsFrom, opt.UserPassword))
-	if err != nil {
-		return nil, err
+	// Block here until we're told to shut down, then let the clients finish
+	// their tests, and don't leave the databases behind.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, os.Interrupt)
+	sig := <-sigCh
+	drainTimeout := getDurationEnv("PROVIDER_DRAIN_TIMEOUT")
+	if drainTimeout == 0 {
+		drainTimeout = defaultDrainTimeout
 	}
+	glog.Infof("Received %s, draining leases for up to %s", sig, drainTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	svc.Drain(drainCtx)
+	cancel()
 
-	// The backend user can only manipulate rows, not tables (or other things).
-	err = runMysqlCmd(newCtx, db,
-		fmt.Sprintf("GRANT SELECT, INSERT, UPDATE, DELETE ON *.* TO '%s'@'%s'",
-			opt.User, allowConnectionsFrom))
-	if err != nil {
-		return nil, err
+	stopLessors()
+	wg.Wait()
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	for _, l := range lessors {
+		l.Cleanup(cleanupCtx) // errors are logged
+	}
+	for _, s := range servers {
+		for _, lock := range s.locks {
+			lock.release(cleanupCtx)
+		}
 	}
+	cancel()
+	r.GracefulStop()
+	glog.Info("Shutdown complete")
+	glog.Flush()
+}
 
-	// Flush privileges to force the settings to take effect.
-	if err := runMysqlCmd(newCtx, db, "FLUSH PRIVILEGES"); err != nil {
-		return nil, err
+// Returns the path of the pool's journal in the configured directory, or empty
+// if there is none.
+func journalPath(pool string) string {
+	dir := os.Getenv("PROVIDER_JOURNAL_DIR")
+	if dir == "" {
+		return ""
 	}
+	return filepath.Join(dir, pool+".journal")
+}
 
-	return p, nil
+// Returns the provider of a pool's databases, which spreads them over the
+// servers if there are several.
+func poolProvider(servers []*server, settings PoolSettings, profiles []Profile) databaseprovider.DatabaseProvider {
+	newProvider := func(s *server) *MysqlProvider {
+		return &MysqlProvider{Conn: s.provider.Conn, DB: s.provider.DB, Settings: settings, Profiles: profiles}
+	}
+	if len(servers) == 1 {
+		return newProvider(servers[0])
+	}
+	var backends []multi.Backend
+	for _, s := range servers {
+		backends = append(backends, multi.Backend{
+			Name:     s.config.Name,
+			Provider: &backendProvider{MysqlProvider: newProvider(s), server: s},
+			Weight:   s.config.Weight,
+			Err:      s.startErr,
+		})
+	}
+	return multi.New(backends...)
 }
 
 // Runs a mysql command, and retries continually until the command succeeds or
@@ -127,6 +289,21 @@ func runMysqlCmd(ctx context.Context, db *sql.DB, cmd string) error {
 	}
 }
 
+const (
+	// How long to wait for the servers to come up at startup.
+	initTimeout = 3 * time.Minute
+
+	// How long to wait for clients to return their leases when shutting down,
+	// unless configured.
+	defaultDrainTimeout = time.Minute
+
+	// How long to spend dropping the databases when shutting down.
+	cleanupTimeout = time.Minute
+
+	// How often to check whether the backends are up, when there are several.
+	backendCheckInterval = 10 * time.Second
+)
+
 func getEnvOrDie(key string) string {
 	ret := os.Getenv(key)
 	if ret == "" {
@@ -135,6 +312,45 @@ func getEnvOrDie(key string) string {
 	return ret
 }
 
+// Gets an optional duration from the environment, which is zero if not set.
+func getDurationEnv(key string) time.Duration {
+	s := os.Getenv(key)
+	if s == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		glog.Fatalf("Invalid duration in %s: %s", key, err)
+	}
+	return d
+}
+
+// Gets an optional integer from the environment, which is zero if not set.
+func getIntEnv(key string) int {
+	s := os.Getenv(key)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		glog.Fatalf("Invalid integer in %s: %s", key, err)
+	}
+	return n
+}
+
+// Gets an optional boolean from the environment, which is false if not set.
+func getBoolEnv(key string) bool {
+	s := os.Getenv(key)
+	if s == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		glog.Fatalf("Invalid boolean in %s: %s", key, err)
+	}
+	return b
+}
+
 // Gets the connection parameters from the environment.
 func getConnectionParamsOrDie() *MysqlConnParams {
 	mysqlPort, err := strconv.Atoi(getEnvOrDie("PROVIDER_MYSQL_PORT"))
@@ -143,10 +359,9 @@ func getConnectionParamsOrDie() *MysqlConnParams {
 	}
 
 	return &MysqlConnParams{
-		User:         getEnvOrDie("PROVIDER_MYSQL_USER"),
-		UserPassword: getEnvOrDie("PROVIDER_MYSQL_USER_PASSWORD"),
 		RootPassword: getEnvOrDie("MYSQL_ROOT_PASSWORD"),
 		MysqlAddress: getEnvOrDie("PROVIDER_MYSQL_ADDRESS"),
 		MysqlPort:    mysqlPort,
+		UserHost:     getEnvOrDie("MYSQL_ROOT_HOST"),
 	}
 }
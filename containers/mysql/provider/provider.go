@@ -2,17 +2,19 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"fmt"
+	"strings"
 
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
 	pb "github.com/karagog/db-provider/server/proto"
 )
 
 // MysqlConnParams tells us how to connect to a MysqlProvider server.
 type MysqlConnParams struct {
-	// The user names/passwords for connecting to the MySQL server.
-	User         string
-	UserPassword string
+	// The root password for connecting to the MySQL server. It's never given
+	// to clients, which connect as the users created for each lease.
 	RootPassword string
 
 	// The IP address of the MySQL instance.
@@ -20,6 +22,24 @@ type MysqlConnParams struct {
 
 	// The port number of the MySQL service.
 	MysqlPort int
+
+	// The host from which the users created for each lease may connect, in
+	// MySQL's syntax. Empty means any host.
+	UserHost string
+}
+
+// PoolSettings customize the databases of a pool.
+type PoolSettings struct {
+	// The default character set and collation of new databases. Leave empty to
+	// use the server defaults.
+	Charset   string
+	Collation string
+
+	// The sql_mode that clients should set on their sessions.
+	SQLMode string
+
+	// SQL statements that seed every new database.
+	Schema string
 }
 
 // MysqlProvider implements DatabaseProvider for MysqlProvider databases.
@@ -30,10 +50,22 @@ type MysqlProvider struct {
 
 	// DB is needed to create/drop databases.
 	DB *sql.DB
+
+	// Settings apply to every database this provider creates, so each pool
+	// has its own provider.
+	Settings PoolSettings
+
+	// Profiles are the extra users that CreateUsers creates for every lease.
+	Profiles []Profile
+}
+
+// Ping tells whether the MySQL server is reachable.
+func (m *MysqlProvider) Ping(ctx context.Context) error {
+	return m.DB.PingContext(ctx)
 }
 
 func (m *MysqlProvider) GetConnectionInfo(database string) *pb.ConnectionInfo {
-	return &pb.ConnectionInfo{
+	ci := &pb.ConnectionInfo{
 		RootConn: &pb.ConnectionDetails{
 			User:     "root",
 			Password: m.Conn.RootPassword,
@@ -42,20 +74,335 @@ func (m *MysqlProvider) GetConnectionInfo(database string) *pb.ConnectionInfo {
 			Database: database,
 		},
 		AppConn: &pb.ConnectionDetails{
-			User:     m.Conn.User,
-			Password: m.Conn.UserPassword,
 			Address:  m.Conn.MysqlAddress,
 			Port:     int32(m.Conn.MysqlPort),
 			Database: database,
 		},
 	}
+	if m.Settings.SQLMode != "" {
+		ci.RootConn.SessionVariables = map[string]string{"sql_mode": quote(m.Settings.SQLMode)}
+		ci.AppConn.SessionVariables = map[string]string{"sql_mode": quote(m.Settings.SQLMode)}
+	}
+	return ci
 }
 
 func (m *MysqlProvider) CreateDatabase(ctx context.Context, name string) error {
-	_, err := m.DB.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", name))
+	if err := m.createEmpty(ctx, name); err != nil {
+		return err
+	}
+	if m.Settings.Schema == "" {
+		return nil
+	}
+	return m.execIn(ctx, name, m.Settings.Schema)
+}
+
+func (m *MysqlProvider) CreateTemplate(ctx context.Context, name, schema string) error {
+	if err := m.CreateDatabase(ctx, name); err != nil {
+		return err
+	}
+	return m.execIn(ctx, name, schema)
+}
+
+// CloneDatabase copies the template's tables, including their rows. Other
+// objects, like views and triggers, are not copied.
+func (m *MysqlProvider) CloneDatabase(ctx context.Context, template, name string) error {
+	// The template already has the pool's schema, so start with an empty database.
+	if err := m.createEmpty(ctx, name); err != nil {
+		return err
+	}
+	return m.copyTables(ctx, template, name)
+}
+
+// ResetDatabase resets the database with the strategy. Only DropAndCreate
+// removes the views, triggers and routines that clients may have created.
+func (m *MysqlProvider) ResetDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	switch strategy {
+	case databaseprovider.Truncate:
+		return m.truncateTables(ctx, database, template)
+	case databaseprovider.RestoreTemplate:
+		if err := m.dropTables(ctx, database); err != nil {
+			return err
+		}
+		if template != "" {
+			return m.copyTables(ctx, template, database)
+		}
+		if m.Settings.Schema == "" {
+			return nil
+		}
+		return m.execIn(ctx, database, m.Settings.Schema)
+	case databaseprovider.DropAndCreate:
+		if err := m.DropDatabase(ctx, database); err != nil {
+			return err
+		}
+		if template == "" {
+			return m.CreateDatabase(ctx, database)
+		}
+		return m.CloneDatabase(ctx, template, database)
+	}
+	return fmt.Errorf("unsupported reset strategy %s", strategy)
+}
+
+// Checkpoint copies the database's tables into a shadow database, which is
+// hidden from the pool by its name.
+func (m *MysqlProvider) Checkpoint(ctx context.Context, database, checkpoint string) error {
+	shadow := checkpointDatabase(database, checkpoint)
+	if err := m.DropDatabase(ctx, shadow); err != nil {
+		return err
+	}
+	if err := m.createEmpty(ctx, shadow); err != nil {
+		return err
+	}
+	return m.copyTables(ctx, database, shadow)
+}
+
+// Rollback replaces the database's tables with those of the shadow database.
+// The database itself is kept, so clients don't lose their connections.
+func (m *MysqlProvider) Rollback(ctx context.Context, database, checkpoint string) error {
+	if err := m.dropTables(ctx, database); err != nil {
+		return err
+	}
+	return m.copyTables(ctx, checkpointDatabase(database, checkpoint), database)
+}
+
+func (m *MysqlProvider) DropCheckpoints(ctx context.Context, database string) error {
+	shadows, err := m.ListDatabases(ctx, checkpointPrefix(database))
+	if err != nil {
+		return err
+	}
+	for _, s := range shadows {
+		if err := m.DropDatabase(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MysqlProvider) ListDatabases(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := m.DB.QueryContext(ctx,
+		"SELECT schema_name FROM information_schema.schemata WHERE schema_name LIKE ? ORDER BY schema_name",
+		escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// RetainDatabase moves the database's tables into the retained database, which
+// is much faster than copying them. Other objects, like views and triggers,
+// are left behind.
+func (m *MysqlProvider) RetainDatabase(ctx context.Context, database, retained string) error {
+	if err := m.createEmpty(ctx, retained); err != nil {
+		return err
+	}
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tables, err := listTables(ctx, conn, database)
+	if err != nil || len(tables) == 0 {
+		return err
+	}
+	var renames []string
+	for _, t := range tables {
+		renames = append(renames, fmt.Sprintf("%s.`%s` TO %s.`%s`", database, t, retained, t))
+	}
+	// Renaming them all in one statement keeps the foreign keys intact.
+	_, err = conn.ExecContext(ctx, "RENAME TABLE "+strings.Join(renames, ", "))
 	return err
 }
 
+// Returns the prefix of the names of the database's shadow databases.
+func checkpointPrefix(database string) string {
+	return database + "__ckpt_"
+}
+
+// Returns the name of the shadow database that holds the checkpoint. The name
+// is hashed, because clients may choose any name for their checkpoints.
+func checkpointDatabase(database, checkpoint string) string {
+	h := sha256.Sum256([]byte(checkpoint))
+	return fmt.Sprintf("%s%x", checkpointPrefix(database), h[:6])
+}
+
+// Escapes the wildcards in a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "_", `\_`, "%", `\%`).Replace(s)
+}
+
+// Copies the tables from one database to another, including their rows.
+func (m *MysqlProvider) copyTables(ctx context.Context, from, to string) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tables, err := listTables(ctx, conn, from)
+	if err != nil {
+		return err
+	}
+
+	// The tables may reference each other, and we create them in arbitrary order.
+	if _, err := conn.ExecContext(ctx, "SET SESSION foreign_key_checks = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SET SESSION foreign_key_checks = 1")
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("USE %s", to)); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		// Unlike CREATE TABLE ... LIKE, this preserves foreign keys.
+		var table, create string
+		if err := conn.QueryRowContext(ctx, fmt.Sprintf("SHOW CREATE TABLE %s.`%s`", from, t)).Scan(&table, &create); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, create); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO `%s` SELECT * FROM %s.`%s`", t, from, t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Empties all the tables in the database, and copies the rows of the
+// template's tables into them, if there is a template.
+func (m *MysqlProvider) truncateTables(ctx context.Context, database, template string) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tables, err := listTables(ctx, conn, database)
+	if err != nil {
+		return err
+	}
+	var rows []string
+	if template != "" {
+		if rows, err = listTables(ctx, conn, template); err != nil {
+			return err
+		}
+	}
+	// The rows may reference each other, and we insert them in arbitrary order.
+	if _, err := conn.ExecContext(ctx, "SET SESSION foreign_key_checks = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SET SESSION foreign_key_checks = 1")
+	for _, t := range tables {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s.`%s`", database, t)); err != nil {
+			return err
+		}
+	}
+	for _, t := range rows {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s.`%s` SELECT * FROM %s.`%s`", database, t, template, t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drops all the tables in the database.
+func (m *MysqlProvider) dropTables(ctx context.Context, database string) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tables, err := listTables(ctx, conn, database)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "SET SESSION foreign_key_checks = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SET SESSION foreign_key_checks = 1")
+	for _, t := range tables {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s.`%s`", database, t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates the database with the pool's charset and collation, but without its schema.
+func (m *MysqlProvider) createEmpty(ctx context.Context, name string) error {
+	cmd := fmt.Sprintf("CREATE DATABASE %s", name)
+	if m.Settings.Charset != "" {
+		cmd += fmt.Sprintf(" CHARACTER SET %s", m.Settings.Charset)
+	}
+	if m.Settings.Collation != "" {
+		cmd += fmt.Sprintf(" COLLATE %s", m.Settings.Collation)
+	}
+	_, err := m.DB.ExecContext(ctx, cmd)
+	return err
+}
+
+// Can run queries, e.g. *sql.Conn or *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Lists the names of the base tables in the database, in alphabetical order.
+func listTables(ctx context.Context, q querier, database string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = ? AND table_type = 'BASE TABLE'
+		ORDER BY table_name`, database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		ret = append(ret, t)
+	}
+	return ret, rows.Err()
+}
+
+// Executes the SQL statements with the given database selected. The session
+// uses the pool's sql_mode, just like the clients' sessions would.
+func (m *MysqlProvider) execIn(ctx context.Context, database, stmts string) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if m.Settings.SQLMode != "" {
+		// Restore the default on our way out, so the pooled connection is not tainted.
+		if _, err := conn.ExecContext(ctx, "SET SESSION sql_mode = "+quote(m.Settings.SQLMode)); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "SET SESSION sql_mode = DEFAULT")
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("USE %s", database)); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, stmts)
+	return err
+}
+
+// Quotes the string as a SQL string literal.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 func (m *MysqlProvider) DropDatabase(ctx context.Context, name string) error {
 	_, err := m.DB.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", name))
 	return err
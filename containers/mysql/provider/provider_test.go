@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/go-test/deep"
@@ -10,8 +11,6 @@ import (
 func TestConnectionInfo(t *testing.T) {
 	m := MysqlProvider{
 		Conn: MysqlConnParams{
-			User:         "George",
-			UserPassword: "1234",
 			RootPassword: "5678",
 			MysqlAddress: "localhost",
 			MysqlPort:    3306,
@@ -20,8 +19,6 @@ func TestConnectionInfo(t *testing.T) {
 	ci := m.GetConnectionInfo("mydb")
 	diff := deep.Equal(ci, &pb.ConnectionInfo{
 		AppConn: &pb.ConnectionDetails{
-			User:     "George",
-			Password: "1234",
 			Address:  "localhost",
 			Port:     3306,
 			Database: "mydb",
@@ -38,3 +35,41 @@ func TestConnectionInfo(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestConnectionInfoSQLMode(t *testing.T) {
+	m := MysqlProvider{
+		Settings: PoolSettings{SQLMode: "ANSI_QUOTES,NO_ZERO_DATE"},
+	}
+	ci := m.GetConnectionInfo("mydb")
+	want := map[string]string{"sql_mode": "'ANSI_QUOTES,NO_ZERO_DATE'"}
+	if diff := deep.Equal(ci.RootConn.SessionVariables, want); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(ci.AppConn.SessionVariables, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestCheckpointDatabase(t *testing.T) {
+	name := checkpointDatabase("testserver_db_0", "after setup")
+	if got, want := name, "testserver_db_0__ckpt_"; !strings.HasPrefix(got, want) {
+		t.Fatalf("Got %q, want prefix %q", got, want)
+	}
+	if name == checkpointDatabase("testserver_db_0", "other") {
+		t.Fatal("Got the same shadow database for different checkpoints")
+	}
+	if got, want := escapeLike(`a_b%c\d`), `a\_b\%c\\d`; got != want {
+		t.Fatalf("Got %q, want %q", got, want)
+	}
+}
+
+func TestAccount(t *testing.T) {
+	m := &MysqlProvider{}
+	if got, want := m.account("lease_1_app"), "'lease_1_app'@'%'"; got != want {
+		t.Fatalf("Got %s, want %s", got, want)
+	}
+	m.Conn.UserHost = "172.17.%"
+	if got, want := m.account("lease_1_app"), "'lease_1_app'@'172.17.%'"; got != want {
+		t.Fatalf("Got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/glog"
+
+	"github.com/karagog/db-provider/client/go/database/mysql"
+)
+
+// A MySQL server over which the databases are spread.
+type server struct {
+	config   BackendConfig  // const
+	provider *MysqlProvider // const; has no pool settings
+	startErr error          // const after startup; why the server was down then, if it was
+	locks    []*prefixLock  // const after startup; reserve the names of our pools' databases on the server
+}
+
+// Returns the server, without connecting to it yet.
+func newServer(bc BackendConfig, params *MysqlConnParams) (*server, error) {
+	p := &MysqlProvider{Conn: *params}
+	db, err := mysql.Connect(p.GetConnectionInfo("").RootConn)
+	if err != nil {
+		return nil, err
+	}
+	p.DB = db
+	return &server{config: bc, provider: p}, nil
+}
+
+// Waits until the server answers, e.g. while it's starting, or the context
+// ends.
+func (s *server) waitUp(ctx context.Context) error {
+	glog.Infof("Connecting to the MySQL server of backend %q", s.config.Name)
+	return runMysqlCmd(ctx, s.provider.DB, "SELECT 1")
+}
+
+// Tells whether the server is usable: it's reachable and we hold the locks on
+// the names of our databases, which are taken if needed when a server that was
+// down at startup comes up. If another provider took one meanwhile, we exit,
+// since we can't use the server.
+func (s *server) ping(ctx context.Context) error {
+	if err := s.provider.Ping(ctx); err != nil {
+		return err
+	}
+	for _, lock := range s.locks {
+		err := lock.ensure(ctx)
+		if errors.Is(err, errLockTaken) {
+			glog.Fatalf("Backend %q: %s", s.config.Name, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The provider of a pool's databases on a server, which can only be used
+// while the server is.
+type backendProvider struct {
+	*MysqlProvider
+	server *server
+}
+
+// Ping tells whether the server is usable.
+func (p *backendProvider) Ping(ctx context.Context) error {
+	return p.server.ping(ctx)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// CreateUsers creates users that only have access to the database: one
+// with all privileges for the root connection, one that can only
+// manipulate rows for the app connection, and one for each of the
+// configured profiles. If any of them can't be created, the others are
+// dropped again.
+func (m *MysqlProvider) CreateUsers(ctx context.Context, database string) (*pb.ConnectionInfo, error) {
+	id := randomHex(6)
+	info := m.GetConnectionInfo(database)
+	info.RootConn.User, info.RootConn.Password = "lease_"+id+"_root", randomHex(16)
+	info.AppConn.User, info.AppConn.Password = "lease_"+id+"_app", randomHex(16)
+
+	// Underscores are wildcards in the database names of grants, so escape
+	// them to grant access to this database only.
+	on := fmt.Sprintf("`%s`.*", escapeLike(database))
+	users := []newUser{
+		{m.account(info.RootConn.User), info.RootConn.Password, "ALL PRIVILEGES"},
+		{m.account(info.AppConn.User), info.AppConn.Password, "SELECT, INSERT, UPDATE, DELETE"},
+	}
+	for _, p := range m.Profiles {
+		if info.Profiles == nil {
+			info.Profiles = make(map[string]*pb.ConnectionDetails)
+		}
+		// The profile users connect like the app user, but as themselves.
+		d := proto.Clone(info.AppConn).(*pb.ConnectionDetails)
+		d.User, d.Password = "lease_"+id+"_"+p.Name, randomHex(16)
+		info.Profiles[p.Name] = d
+		users = append(users, newUser{m.account(d.User), d.Password, strings.Join(p.Privileges, ", ")})
+	}
+	if err := createUsers(ctx, m.DB, on, users); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// A user to create, with its privileges on the database.
+type newUser struct {
+	account, password, privileges string
+}
+
+// Executes statements, like *sql.DB.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Creates the users and grants them their privileges on the database. On
+// error, it drops the users created so far, since DropUsers can't find those
+// that haven't been granted access to the database yet.
+func createUsers(ctx context.Context, db execer, on string, users []newUser) error {
+	var created []string
+	err := func() error {
+		for _, u := range users {
+			if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s", u.account, quote(u.password))); err != nil {
+				return err
+			}
+			created = append(created, u.account)
+			if _, err := db.ExecContext(ctx, fmt.Sprintf("GRANT %s ON %s TO %s", u.privileges, on, u.account)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		for _, a := range created {
+			if _, err := db.ExecContext(ctx, "DROP USER IF EXISTS "+a); err != nil {
+				glog.Errorf("Error dropping user %s: %s", a, err)
+			}
+		}
+	}
+	return err
+}
+
+// DropUsers drops every user that has been granted access to the database
+// specifically, and kills their connections, since dropping a user doesn't.
+func (m *MysqlProvider) DropUsers(ctx context.Context, database string) error {
+	rows, err := m.DB.QueryContext(ctx,
+		"SELECT DISTINCT user, host FROM mysql.db WHERE db = ?", escapeLike(database))
+	if err != nil {
+		return err
+	}
+	type account struct{ user, host string }
+	var accounts []account
+	for rows.Next() {
+		var a account
+		if err := rows.Scan(&a.user, &a.host); err != nil {
+			rows.Close()
+			return err
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range accounts {
+		if err := m.killConnections(ctx, a.user); err != nil {
+			return err
+		}
+		glog.V(2).Infof("Dropping user %s@%s of database %s", a.user, a.host, database)
+		if _, err := m.DB.ExecContext(ctx, fmt.Sprintf("DROP USER IF EXISTS %s@%s", quote(a.user), quote(a.host))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Kills the user's connections to the server.
+func (m *MysqlProvider) killConnections(ctx context.Context, user string) error {
+	rows, err := m.DB.QueryContext(ctx,
+		"SELECT id FROM information_schema.processlist WHERE user = ?", user)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		// The connection may have closed in the meantime, which is fine.
+		if _, err := m.DB.ExecContext(ctx, fmt.Sprintf("KILL %d", id)); err != nil {
+			glog.V(2).Infof("Error killing connection %d of %s: %s", id, user, err)
+		}
+	}
+	return nil
+}
+
+// Returns the account of the user, who may connect from the configured host.
+func (m *MysqlProvider) account(user string) string {
+	host := m.Conn.UserHost
+	if host == "" {
+		host = "%"
+	}
+	return fmt.Sprintf("%s@%s", quote(user), quote(host))
+}
+
+// Returns a random hex string of n bytes.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", b)
+}
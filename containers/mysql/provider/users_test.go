@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// Records the statements, and fails the first one with the prefix.
+type failingExecer struct {
+	failPrefix string
+	stmts      []string
+}
+
+func (e *failingExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.stmts = append(e.stmts, query)
+	if e.failPrefix != "" && strings.HasPrefix(query, e.failPrefix) {
+		e.failPrefix = ""
+		return nil, errors.New("Oof!")
+	}
+	return nil, nil
+}
+
+func TestCreateUsersFailure(t *testing.T) {
+	users := []newUser{
+		{"'root'@'%'", "pw1", "ALL PRIVILEGES"},
+		{"'app'@'%'", "pw2", "SELECT"},
+		{"'ro'@'%'", "pw3", "SELECT"},
+	}
+	for _, tc := range []struct {
+		desc, failPrefix string
+		want             []string
+	}{
+		{"success", "", []string{
+			"CREATE USER 'root'@'%' IDENTIFIED BY 'pw1'",
+			"GRANT ALL PRIVILEGES ON `db`.* TO 'root'@'%'",
+			"CREATE USER 'app'@'%' IDENTIFIED BY 'pw2'",
+			"GRANT SELECT ON `db`.* TO 'app'@'%'",
+			"CREATE USER 'ro'@'%' IDENTIFIED BY 'pw3'",
+			"GRANT SELECT ON `db`.* TO 'ro'@'%'",
+		}},
+		{"create", "CREATE USER 'ro'", []string{
+			"CREATE USER 'root'@'%' IDENTIFIED BY 'pw1'",
+			"GRANT ALL PRIVILEGES ON `db`.* TO 'root'@'%'",
+			"CREATE USER 'app'@'%' IDENTIFIED BY 'pw2'",
+			"GRANT SELECT ON `db`.* TO 'app'@'%'",
+			"CREATE USER 'ro'@'%' IDENTIFIED BY 'pw3'",
+			"DROP USER IF EXISTS 'root'@'%'",
+			"DROP USER IF EXISTS 'app'@'%'",
+		}},
+		// A user that wasn't granted access yet is dropped as well.
+		{"grant", "GRANT SELECT ON `db`.* TO 'app'", []string{
+			"CREATE USER 'root'@'%' IDENTIFIED BY 'pw1'",
+			"GRANT ALL PRIVILEGES ON `db`.* TO 'root'@'%'",
+			"CREATE USER 'app'@'%' IDENTIFIED BY 'pw2'",
+			"GRANT SELECT ON `db`.* TO 'app'@'%'",
+			"DROP USER IF EXISTS 'root'@'%'",
+			"DROP USER IF EXISTS 'app'@'%'",
+		}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			e := &failingExecer{failPrefix: tc.failPrefix}
+			err := createUsers(context.Background(), e, "`db`.*", users)
+			if (err != nil) != (tc.failPrefix != "") {
+				t.Fatalf("Got error %v, want error %t", err, tc.failPrefix != "")
+			}
+			if diff := deep.Equal(e.stmts, tc.want); diff != nil {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+
+	"github.com/karagog/db-provider/client/go/database/mysql"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+)
+
+// VerifyDatabase checks that the database has the pool's charset and
+// collation, that it has the template's tables and no other objects, and that
+// a lease's app user can access it. After the database was truncated, it may
+// also have other tables, as long as they're empty. The objects of a database
+// seeded by the pool's schema aren't checked, since the schema may create
+// anything.
+func (m *MysqlProvider) VerifyDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	if err := m.verifyCharset(ctx, database); err != nil {
+		return err
+	}
+	if template != "" || m.Settings.Schema == "" {
+		if err := m.verifyObjects(ctx, database, template, strategy); err != nil {
+			return err
+		}
+	}
+	return m.verifyAppAccess(ctx, database)
+}
+
+// Checks that the database has the pool's charset and collation, or the
+// server's defaults.
+func (m *MysqlProvider) verifyCharset(ctx context.Context, database string) error {
+	var charset, collation string
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT default_character_set_name, default_collation_name
+		FROM information_schema.schemata WHERE schema_name = ?`, database).Scan(&charset, &collation)
+	if err != nil {
+		return fmt.Errorf("reading the charset of %s: %v", database, err)
+	}
+	wantCharset, wantCollation := m.Settings.Charset, m.Settings.Collation
+	if wantCharset == "" && wantCollation == "" {
+		err := m.DB.QueryRowContext(ctx,
+			"SELECT @@global.character_set_server, @@global.collation_server").Scan(&wantCharset, &wantCollation)
+		if err != nil {
+			return err
+		}
+	}
+	if wantCharset != "" && !strings.EqualFold(charset, wantCharset) {
+		return fmt.Errorf("database %s has charset %s, want %s", database, charset, wantCharset)
+	}
+	if wantCollation != "" && !strings.EqualFold(collation, wantCollation) {
+		return fmt.Errorf("database %s has collation %s, want %s", database, collation, wantCollation)
+	}
+	return nil
+}
+
+// Checks that the database has the same tables as the template, or none, and
+// no views, routines, triggers or events, which aren't cloned. Truncating
+// keeps the tables that the clients created, so those only have to be empty.
+func (m *MysqlProvider) verifyObjects(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tables, err := listTables(ctx, conn, database)
+	if err != nil {
+		return err
+	}
+	var want []string
+	if template != "" {
+		if want, err = listTables(ctx, conn, template); err != nil {
+			return err
+		}
+	}
+	var problems []string
+	if strategy == databaseprovider.Truncate {
+		missing, extra := diffTables(tables, want)
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("no tables %q", missing))
+		}
+		for _, t := range extra {
+			var rows bool
+			if err := conn.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s.`%s`)", database, t)).Scan(&rows); err != nil {
+				return err
+			}
+			if rows {
+				problems = append(problems, fmt.Sprintf("rows in table %s", t))
+			}
+		}
+	} else if strings.Join(tables, ",") != strings.Join(want, ",") {
+		problems = append(problems, fmt.Sprintf("tables %q, want %q", tables, want))
+	}
+	for _, q := range []struct{ kind, query string }{
+		{"views", "SELECT COUNT(*) FROM information_schema.views WHERE table_schema = ?"},
+		{"routines", "SELECT COUNT(*) FROM information_schema.routines WHERE routine_schema = ?"},
+		{"triggers", "SELECT COUNT(*) FROM information_schema.triggers WHERE trigger_schema = ?"},
+		{"events", "SELECT COUNT(*) FROM information_schema.events WHERE event_schema = ?"},
+	} {
+		var n int
+		if err := conn.QueryRowContext(ctx, q.query, database).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			problems = append(problems, fmt.Sprintf("%d %s", n, q.kind))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("database %s has %s", database, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Returns the wanted tables that are missing, and the tables that aren't
+// wanted.
+func diffTables(have, want []string) (missing, extra []string) {
+	set := make(map[string]bool)
+	for _, t := range have {
+		set[t] = true
+	}
+	for _, t := range want {
+		if !set[t] {
+			missing = append(missing, t)
+		}
+		delete(set, t)
+	}
+	for _, t := range have {
+		if set[t] {
+			extra = append(extra, t)
+		}
+	}
+	return missing, extra
+}
+
+// Checks that an app user like those of a lease can connect to the database,
+// by creating one and dropping it again.
+func (m *MysqlProvider) verifyAppAccess(ctx context.Context, database string) error {
+	info, err := m.CreateUsers(ctx, database)
+	if err != nil {
+		return fmt.Errorf("creating users for %s: %v", database, err)
+	}
+	defer func() {
+		if err := m.DropUsers(ctx, database); err != nil {
+			glog.Errorf("Error dropping the users that verified %s: %s", database, err)
+		}
+	}()
+	db, err := mysql.Connect(info.AppConn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("the app user can't access %s: %v", database, err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDiffTables(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		have, want     []string
+		missing, extra []string
+	}{
+		{"none", nil, nil, nil, nil},
+		{"same", []string{"a", "b"}, []string{"a", "b"}, nil, nil},
+		{"migrated", []string{"a", "b", "c"}, []string{"b"}, nil, []string{"a", "c"}},
+		{"dropped", []string{"b"}, []string{"a", "b"}, []string{"a"}, nil},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			missing, extra := diffTables(tc.have, tc.want)
+			if diff := deep.Equal(missing, tc.missing); diff != nil {
+				t.Errorf("Missing: %v", diff)
+			}
+			if diff := deep.Equal(extra, tc.extra); diff != nil {
+				t.Errorf("Extra: %v", diff)
+			}
+		})
+	}
+}
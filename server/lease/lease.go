@@ -3,10 +3,15 @@ package lease
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"time"
 
 	"github.com/golang/glog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/karagog/db-provider/server/proto"
 )
@@ -17,35 +22,57 @@ var fatalf = func(msg string, args ...interface{}) { glog.Fatalf(msg, args...) }
 
 // Lease holds and maintains a lease on an instance maintained by the test server.
 //
-// Create with Acquire(), and then call `go Run()` to maintain the lease. It may take a
-// while for a lease to be granted, so call Wait() to block until it is.
+// Create with New(), and then call `go Run()` to maintain the lease. It may take a
+// while for a lease to be granted, so call ConnectionInfo() to block until it is.
 //
 // When you're done with the lease, call Close() to relinquish it.
 type Lease struct {
-	stream   pb.IntegrationTest_GetDatabaseInstanceClient
-	ch       chan *pb.ConnectionInfo
-	connInfo *pb.ConnectionInfo
+	client    pb.IntegrationTestClient
+	stream    pb.IntegrationTest_GetDatabaseInstanceClient
+	token     string // set before the connection infos are sent on ch
+	ch        chan []*pb.ConnectionInfo
+	connInfos []*pb.ConnectionInfo
+	err       error // why the request failed, if it did; set before ch is closed
+}
+
+// WaitTimeoutError means the server gave up waiting for a database to become
+// available, for example because the pool is too small for the load.
+type WaitTimeoutError struct {
+	// The server's explanation, including the size of the pool and its queue.
+	Message string
+}
+
+func (e *WaitTimeoutError) Error() string {
+	return fmt.Sprintf("timed out waiting for a database: %s", e.Message)
 }
 
 // Requests a new lease from the server. You must call 'go Run()' before using.
 // Good citizens return the lease explicitly by calling Close(),
 // although it will be returned automatically when the connection is
 // broken for any reason.
-func New(ctx context.Context, serviceAddr string) (*Lease, error) {
+//
+// The request says what kind of database you want, or pass nil to get one
+// from the default pool.
+func New(ctx context.Context, serviceAddr string, req *pb.GetDatabaseInstanceRequest) (*Lease, error) {
+	if req == nil {
+		req = &pb.GetDatabaseInstanceRequest{}
+	}
 	conn, err := grpc.Dial(serviceAddr, grpc.WithInsecure())
 	if err != nil {
 		return nil, err
 	}
-	stream, err := pb.NewIntegrationTestClient(conn).GetDatabaseInstance(ctx)
+	client := pb.NewIntegrationTestClient(conn)
+	stream, err := client.GetDatabaseInstance(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if err := stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+	if err := stream.Send(req); err != nil {
 		return nil, err
 	}
 	return &Lease{
+		client: client,
 		stream: stream,
-		ch:     make(chan *pb.ConnectionInfo),
+		ch:     make(chan []*pb.ConnectionInfo),
 	}, nil
 }
 
@@ -54,44 +81,143 @@ func New(ctx context.Context, serviceAddr string) (*Lease, error) {
 // connection to the database provider service.
 //
 // This ends when you return the lease, or there's an error from the server.
+// An error before the lease is granted is reported by ConnectionInfo().
 func (l *Lease) Run() {
 	defer close(l.ch)
+	granted := false
 	for {
 		resp, err := l.stream.Recv()
 		if err != nil {
 			if err == io.EOF {
 				return
 			}
+			if !granted {
+				l.err = requestError(err)
+				return
+			}
 			// A sudden loss of the lease is a fatal error that should abort
 			// the test program immediately to avoid conflicting with another test.
 			fatalf("Halting program due loss of lease on the test database: %v", err)
 		}
-		if resp.Status != "" {
+		if resp.ExpiryWarning || resp.ShuttingDown {
+			glog.Warningf("Received server warning: %s", resp.Status)
+		} else if resp.Status != "" {
 			glog.V(1).Infof("Received server status: %s", resp.Status)
 		}
+		for _, r := range resp.Retained {
+			c := r.ConnectionInfo.RootConn
+			glog.Warningf("Database %s retained for debugging until %v, connect to %s:%d as %s",
+				c.Database, r.ExpireTime.AsTime().Format(time.RFC3339), c.Address, c.Port, c.User)
+		}
 		if resp.ConnectionInfo == nil {
 			continue // server is still processing our request...
 		}
 		glog.V(1).Infof("Got connection info from the server:\n%v", resp)
-		l.ch <- resp.ConnectionInfo
+		granted = true
+		l.token = resp.Token
+		infos := resp.ConnectionInfos
+		if len(infos) == 0 {
+			infos = []*pb.ConnectionInfo{resp.ConnectionInfo} // an older server
+		}
+		l.ch <- infos
 	}
 }
 
+// Converts an error that ended the request before the lease was granted.
+func requestError(err error) error {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.ResourceExhausted {
+		return &WaitTimeoutError{Message: st.Message()}
+	}
+	return err
+}
+
+// Retain asks the server to keep the databases for debugging when the lease
+// is released, instead of resetting them right away, e.g. because the test
+// failed. How to connect to them is logged when the lease is closed.
+func (l *Lease) Retain() error {
+	return l.stream.Send(&pb.GetDatabaseInstanceRequest{RetainOnRelease: true})
+}
+
 // Close closes the object and releases the lease. This must be called
 // when you're done with it.
 func (l *Lease) Close() {
-	l.connInfo = nil
+	l.connInfos = nil
 	l.stream.CloseSend()
 	<-l.ch // join the goroutine method
 }
 
 // ConnectionInfo returns the connection info to the database on
-// which it holds a lease. It blocks indefinitely until the lease is acquired
-// and the connection info is available. The result is cached, so
-// subsequent calls return immediately.
-func (l *Lease) ConnectionInfo() *pb.ConnectionInfo {
-	if l.connInfo == nil {
-		l.connInfo = <-l.ch
+// which it holds a lease, or the first one if there are several. It blocks
+// indefinitely until the lease is acquired and the connection info is
+// available, or the request fails. In particular it returns a
+// *WaitTimeoutError if the server gave up waiting for a database.
+// The result is cached, so subsequent calls return immediately.
+func (l *Lease) ConnectionInfo() (*pb.ConnectionInfo, error) {
+	infos, err := l.ConnectionInfos()
+	if err != nil {
+		return nil, err
+	}
+	return infos[0], nil
+}
+
+// ConnectionInfos is like ConnectionInfo(), but returns the connection info
+// to each of the databases on which it holds a lease.
+func (l *Lease) ConnectionInfos() ([]*pb.ConnectionInfo, error) {
+	if l.connInfos == nil {
+		infos, ok := <-l.ch
+		if !ok {
+			if l.err != nil {
+				return nil, l.err
+			}
+			return nil, errors.New("lease ended before it was granted")
+		}
+		l.connInfos = infos
+	}
+	return l.connInfos, nil
+}
+
+// Checkpoint saves the state of the leased databases under the name, so you
+// can roll back to it later with Rollback(). Taking a checkpoint with the same
+// name again replaces it. It blocks until the lease is granted.
+func (l *Lease) Checkpoint(ctx context.Context, name string) error {
+	if _, err := l.ConnectionInfos(); err != nil {
+		return err
+	}
+	_, err := l.client.Checkpoint(ctx, &pb.CheckpointRequest{Token: l.token, Name: name})
+	return err
+}
+
+// Rollback restores the leased databases to the named checkpoint, which
+// remains so you can roll back to it again.
+func (l *Lease) Rollback(ctx context.Context, name string) error {
+	if _, err := l.ConnectionInfos(); err != nil {
+		return err
+	}
+	_, err := l.client.Rollback(ctx, &pb.RollbackRequest{Token: l.token, Name: name})
+	return err
+}
+
+// Dump writes a SQL dump of the schema and data of the named database, which
+// must be one of the leased databases, or the first one if the name is empty.
+// It blocks until the lease is granted.
+func (l *Lease) Dump(ctx context.Context, database string, w io.Writer) error {
+	if _, err := l.ConnectionInfos(); err != nil {
+		return err
+	}
+	stream, err := l.client.DumpDatabase(ctx, &pb.DumpDatabaseRequest{Token: l.token, Database: database})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(resp.Data); err != nil {
+			return err
+		}
 	}
-	return l.connInfo
 }
@@ -2,25 +2,35 @@ package lease
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-test/deep"
 	"github.com/karagog/clock-go/simulated"
+	"google.golang.org/protobuf/types/known/durationpb"
+
 	"github.com/karagog/db-provider/server/lessor"
 	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+	pb "github.com/karagog/db-provider/server/proto"
 	"github.com/karagog/db-provider/server/service"
 	"github.com/karagog/db-provider/server/service/runner"
 )
 
 // Starts up a fake database provider service in-memory.
 func fakeServiceRunner(numInstances int, t *testing.T) *runner.Runner {
-	l := lessor.New(&fake.DatabaseProvider{}, numInstances)
+	return fakeServiceRunnerWithClock(numInstances, simulated.NewClock(time.Now()), t)
+}
+
+// Like fakeServiceRunner(), but the service uses the given clock.
+func fakeServiceRunnerWithClock(numInstances int, c *simulated.Clock, t *testing.T) *runner.Runner {
+	l := lessor.New(&fake.DatabaseProvider{}, lessor.Config{Size: numInstances})
 	go l.Run(context.Background())
 
-	svc := service.New(simulated.NewClock(time.Now()))
-	svc.SetLessor(l)
+	svc := service.New(c, service.Options{})
+	svc.SetLessors(l)
 	r, err := runner.New(svc, "localhost:0")
 	if err != nil {
 		t.Fatal(err)
@@ -33,7 +43,7 @@ func TestLease(t *testing.T) {
 	go r.Run()
 	defer r.Stop()
 
-	l, err := New(context.Background(), r.Address())
+	l, err := New(context.Background(), r.Address(), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -47,13 +57,16 @@ func TestLease(t *testing.T) {
 	}()
 
 	// Get the connection info, which blocks until we get a lease.
-	info := l.ConnectionInfo()
-	if info == nil {
-		t.Fatalf("Got nil connection info, want non-nil")
+	info, err := l.ConnectionInfo()
+	if err != nil {
+		t.Fatal(err)
 	}
 
 	// Check that the result was cached by calling it again.
-	info2 := l.ConnectionInfo()
+	info2, err := l.ConnectionInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
 	if diff := deep.Equal(info2, info); diff != nil {
 		t.Fatal(diff)
 	}
@@ -67,6 +80,53 @@ func TestLease(t *testing.T) {
 	}
 }
 
+func TestCheckpoint(t *testing.T) {
+	r := fakeServiceRunner(1, t)
+	go r.Run()
+	defer r.Stop()
+
+	ctx := context.Background()
+	l, err := New(ctx, r.Address(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go l.Run()
+	defer l.Close()
+
+	// Checkpoint waits for the lease to be granted.
+	if err := l.Checkpoint(ctx, "setup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Rollback(ctx, "setup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Rollback(ctx, "bogus"); err == nil {
+		t.Fatal("Got nil error rolling back to a missing checkpoint")
+	}
+}
+
+func TestDump(t *testing.T) {
+	r := fakeServiceRunner(1, t)
+	go r.Run()
+	defer r.Stop()
+
+	ctx := context.Background()
+	l, err := New(ctx, r.Address(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go l.Run()
+	defer l.Close()
+
+	var b strings.Builder
+	if err := l.Dump(ctx, "", &b); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Dump(ctx, "bogus", &b); err == nil {
+		t.Fatal("Got nil error dumping a database we don't hold")
+	}
+}
+
 // If the server disconnects while we're holding the lease, it should crash us.
 func TestServerDisconnectsWhileHoldingLease(t *testing.T) {
 	r := fakeServiceRunner(1, t)
@@ -75,7 +135,7 @@ func TestServerDisconnectsWhileHoldingLease(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	l, err := New(ctx, r.Address())
+	l, err := New(ctx, r.Address(), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,7 +143,7 @@ func TestServerDisconnectsWhileHoldingLease(t *testing.T) {
 	// Convert the fatal method to a panic to allow us to recover and avoid actually
 	// crashing the test.
 	fatalf = func(msg string, args ...interface{}) {
-		panic(fmt.Sprintf(msg, args))
+		panic(fmt.Sprintf(msg, args...))
 	}
 
 	panicCh := make(chan bool) // did the goroutine panic?
@@ -93,8 +153,8 @@ func TestServerDisconnectsWhileHoldingLease(t *testing.T) {
 	}()
 
 	// Grab and hold a lease.
-	if l.ConnectionInfo() == nil {
-		t.Fatal("Got nil connection info, want info")
+	if _, err := l.ConnectionInfo(); err != nil {
+		t.Fatal(err)
 	}
 
 	// Stop the server, which should disconnect us with an error.
@@ -104,8 +164,53 @@ func TestServerDisconnectsWhileHoldingLease(t *testing.T) {
 	}
 }
 
+// If the server gives up waiting for a database, we get a typed error rather than crashing.
+func TestWaitTimeout(t *testing.T) {
+	c := simulated.NewClock(time.Now())
+	r := fakeServiceRunnerWithClock(1, c, t)
+	go r.Run()
+	defer r.Stop()
+	ctx := context.Background()
+
+	// Hold the only database.
+	holder, err := New(ctx, r.Address(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go holder.Run()
+	defer holder.Close()
+	if _, err := holder.ConnectionInfo(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(ctx, r.Address(), &pb.GetDatabaseInstanceRequest{MaxWait: durationpb.New(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go l.Run()
+	defer l.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := l.ConnectionInfo()
+		errCh <- err
+	}()
+
+	// Let time pass until the server gives up.
+	for err = nil; err == nil; {
+		select {
+		case err = <-errCh:
+		case <-time.After(10 * time.Millisecond):
+			c.Advance(time.Minute)
+		}
+	}
+	var waitErr *WaitTimeoutError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("Got error %v, want a WaitTimeoutError", err)
+	}
+}
+
 func TestLesseeDialedWrongAddress(t *testing.T) {
-	if _, err := New(context.Background(), "localhost:1"); err == nil {
+	if _, err := New(context.Background(), "localhost:1", nil); err == nil {
 		t.Fatalf("Got nil error, want error")
 	}
 }
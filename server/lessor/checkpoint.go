@@ -0,0 +1,76 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+// ErrNoSuchCheckpoint means the lease has no checkpoint with the given name.
+var ErrNoSuchCheckpoint = errors.New("no such checkpoint")
+
+// Checkpoint saves the state of the leased databases under the name, so the
+// lessee can Rollback() to it later, e.g. between sub-tests that share an
+// expensive setup. It replaces any previous checkpoint of the same name.
+// The checkpoints are dropped when the lease is returned.
+//
+// Returns ErrNoSuchLease if the lease has ended.
+func (l *Lessor) Checkpoint(ctx context.Context, lease Lease, name string) error {
+	g, err := l.lockGrant(lease)
+	if err != nil {
+		return err
+	}
+	defer g.mu.Unlock()
+	delete(g.checkpoints, name) // in case we fail half way
+	for _, db := range g.info.Databases {
+		l.getDatabase(db).checkpoints = true
+		if err := l.provider.Checkpoint(ctx, db, name); err != nil {
+			return err
+		}
+	}
+	g.checkpoints[name] = true
+	glog.V(1).Infof("Lease %s took checkpoint %q", g.info.ID, name)
+	return nil
+}
+
+// Rollback restores the leased databases to the named checkpoint, which
+// remains so you can roll back to it again.
+//
+// Returns ErrNoSuchLease if the lease has ended, or ErrNoSuchCheckpoint if
+// there is no checkpoint with the name.
+func (l *Lessor) Rollback(ctx context.Context, lease Lease, name string) error {
+	g, err := l.lockGrant(lease)
+	if err != nil {
+		return err
+	}
+	defer g.mu.Unlock()
+	if !g.checkpoints[name] {
+		return fmt.Errorf("%w: %q", ErrNoSuchCheckpoint, name)
+	}
+	for _, db := range g.info.Databases {
+		if err := l.provider.Rollback(ctx, db, name); err != nil {
+			return err
+		}
+	}
+	glog.V(1).Infof("Lease %s rolled back to checkpoint %q", g.info.ID, name)
+	return nil
+}
+
+// Returns the grant with its mutex locked, or ErrNoSuchLease if the lease has ended.
+func (l *Lessor) lockGrant(lease Lease) (*grant, error) {
+	g, ok := lease.(*grant)
+	if !ok {
+		panic(fmt.Sprintf("Invalid lease: %v", lease))
+	}
+	g.mu.Lock()
+	l.mu.Lock()
+	active := l.leases[g.info.ID] == g
+	l.mu.Unlock()
+	if !active {
+		g.mu.Unlock()
+		return nil, ErrNoSuchLease
+	}
+	return g, nil
+}
@@ -0,0 +1,63 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestCheckpointAndRollback(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := les.Rollback(ctx, l, "setup"); !errors.Is(err, ErrNoSuchCheckpoint) {
+		t.Fatalf("Got error %v, want %v", err, ErrNoSuchCheckpoint)
+	}
+	if err := les.Checkpoint(ctx, l, "setup"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := les.Rollback(ctx, l, "setup"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []fake.Checkpoint{{Database: "testserver_db_0", Name: "setup"}}
+	if diff := deep.Equal(p.CheckpointList, want); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.RollbackList, append(want, want...)); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// A failed checkpoint can't be rolled back to.
+	p.CheckpointErr = errors.New("Oof!")
+	if err := les.Checkpoint(ctx, l, "setup"); err != p.CheckpointErr {
+		t.Fatalf("Got error %v, want %v", err, p.CheckpointErr)
+	}
+	if err := les.Rollback(ctx, l, "setup"); !errors.Is(err, ErrNoSuchCheckpoint) {
+		t.Fatalf("Got error %v, want %v", err, ErrNoSuchCheckpoint)
+	}
+
+	// The checkpoints are dropped along with the lease.
+	les.Return(l)
+	if err := les.Checkpoint(ctx, l, "setup"); err != ErrNoSuchLease {
+		t.Fatalf("Got error %v, want %v", err, ErrNoSuchLease)
+	}
+	if _, err := les.Lease(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(p.DropCheckpointsList, []string{"testserver_db_0"}); diff != nil {
+		t.Fatal(diff)
+	}
+}
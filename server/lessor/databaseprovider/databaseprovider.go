@@ -2,6 +2,8 @@ package databaseprovider
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	pb "github.com/karagog/db-provider/server/proto"
 )
@@ -17,7 +19,142 @@ type DatabaseProvider interface {
 	// Drops the database on the server if it exists, otherwise does nothing and returns nil.
 	DropDatabase(context.Context, string) error
 
-	// This should be available after creating a database. It tells users how
-	// to connect to the given database in this instance.
+	// Creates a template database from which other databases can be cloned.
+	// It is created like any other database, and then the schema SQL
+	// statements are executed in it.
+	//
+	// This should fail if the database already exists.
+	CreateTemplate(ctx context.Context, name, schema string) error
+
+	// Creates the database as a copy of the template database, including its
+	// schema and data.
+	//
+	// This should fail if the database already exists.
+	CloneDatabase(ctx context.Context, template, name string) error
+
+	// Resets the existing database with the strategy to how it was created:
+	// as a copy of the template, or like CreateDatabase if the template is empty.
+	ResetDatabase(ctx context.Context, database, template string, strategy ResetStrategy) error
+
+	// Saves a copy of the database's schema and data under the checkpoint
+	// name, replacing any previous checkpoint of the same name. The copy is
+	// hidden from the pool, e.g. in a shadow database.
+	Checkpoint(ctx context.Context, database, checkpoint string) error
+
+	// Restores the database's schema and data from the checkpoint, which
+	// remains so it can be restored again.
+	Rollback(ctx context.Context, database, checkpoint string) error
+
+	// Deletes all the database's checkpoints, if any.
+	DropCheckpoints(ctx context.Context, database string) error
+
+	// Moves the database's schema and data into a new database with the
+	// retained name, where they can be inspected after the database has been
+	// reset, e.g. to debug a failed test.
+	//
+	// This should fail if the retained database already exists.
+	RetainDatabase(ctx context.Context, database, retained string) error
+
+	// Writes SQL statements that recreate the database's schema and data.
+	DumpDatabase(ctx context.Context, database string, w io.Writer) error
+
+	// Creates credentials for a new lease on the database, which only have
+	// access to that database, and returns how to connect with them.
+	CreateUsers(ctx context.Context, database string) (*pb.ConnectionInfo, error)
+
+	// Drops the credentials created for leases on the database, if any, so
+	// their holders can't access the database any more.
+	DropUsers(ctx context.Context, database string) error
+
+	// Lists the databases on the server whose names start with the prefix,
+	// e.g. to find those left over by a previous run.
+	ListDatabases(ctx context.Context, prefix string) ([]string, error)
+
+	// This should be available after creating a database. It tells how to
+	// connect to the given database with the provider's own credentials,
+	// which have access to every database, so it must never be given to
+	// clients, who get the users of CreateUsers() instead.
 	GetConnectionInfo(database string) *pb.ConnectionInfo
 }
+
+// Pinger is implemented by providers that can tell whether their server is
+// reachable.
+type Pinger interface {
+	Ping(context.Context) error
+}
+
+// Verifier is implemented by providers that can check that a database is
+// clean before it's leased.
+type Verifier interface {
+	// Checks that the database is as it was created: as a copy of the
+	// template, or like CreateDatabase if the template is empty, except for
+	// what the reset strategy keeps, e.g. the empty tables after truncating.
+	// The error tells what's wrong.
+	VerifyDatabase(ctx context.Context, database, template string, strategy ResetStrategy) error
+}
+
+// Availability is implemented by providers whose databases may become
+// unavailable while the others remain usable, e.g. when they are spread over
+// several servers and one of them is down.
+type Availability interface {
+	// Tells whether the database is usable right now.
+	Available(database string) bool
+
+	// Returns a channel that receives when databases may have become
+	// unavailable.
+	AvailabilityChanged() <-chan struct{}
+}
+
+// Reclaimer is implemented by providers that may find databases left over
+// from a previous run after the pool reclaimed its own, e.g. on a server that
+// was down when the pool started.
+type Reclaimer interface {
+	// Makes the provider drop the databases whose names start with the prefix
+	// and that the function says are the pool's, wherever it finds them
+	// later, before it places any databases there.
+	ReclaimLater(prefix string, owned func(name string) bool)
+}
+
+// ResetStrategy is how a database is cleaned up between leases.
+type ResetStrategy int
+
+const (
+	// DropAndCreate drops the database and creates it again, or clones it
+	// from its template again. It's the most thorough, but dropping a
+	// database with many tables is slow, and holds metadata locks.
+	DropAndCreate ResetStrategy = iota
+
+	// Truncate empties every table, and copies the rows of the template into
+	// them again, if any. The schema is kept, so it only suits clients that
+	// don't change it, and the rows inserted by a pool's schema are lost.
+	Truncate
+
+	// RestoreTemplate drops every table, and copies them from the template
+	// again, or recreates them like CreateDatabase if there is no template.
+	// The database itself is kept.
+	RestoreTemplate
+)
+
+// The names of the strategies, in order, as they are configured.
+var resetStrategyNames = []string{"drop", "truncate", "restore"}
+
+func (s ResetStrategy) String() string {
+	if s < 0 || int(s) >= len(resetStrategyNames) {
+		return fmt.Sprintf("ResetStrategy(%d)", int(s))
+	}
+	return resetStrategyNames[s]
+}
+
+// ParseResetStrategy returns the strategy with the name, or DropAndCreate if
+// the name is empty.
+func ParseResetStrategy(name string) (ResetStrategy, error) {
+	if name == "" {
+		return DropAndCreate, nil
+	}
+	for i, n := range resetStrategyNames {
+		if n == name {
+			return ResetStrategy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid reset strategy %q, want one of %q", name, resetStrategyNames)
+}
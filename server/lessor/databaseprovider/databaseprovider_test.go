@@ -1 +1,29 @@
 package databaseprovider
+
+import "testing"
+
+func TestParseResetStrategy(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want ResetStrategy
+	}{
+		{"", DropAndCreate},
+		{"drop", DropAndCreate},
+		{"truncate", Truncate},
+		{"restore", RestoreTemplate},
+	} {
+		got, err := ParseResetStrategy(tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseResetStrategy(%q) = %s, want %s", tc.name, got, tc.want)
+		}
+		if tc.name != "" && got.String() != tc.name {
+			t.Errorf("Got name %q, want %q", got.String(), tc.name)
+		}
+	}
+	if _, err := ParseResetStrategy("vacuum"); err == nil {
+		t.Fatal("Got nil error, want error")
+	}
+}
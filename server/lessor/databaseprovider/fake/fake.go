@@ -3,31 +3,235 @@ package fake
 
 import (
 	"context"
+	"io"
+	"strings"
+	"sync"
 
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
 	pb "github.com/karagog/db-provider/server/proto"
 )
 
+// Clone records a call to CloneDatabase.
+type Clone struct {
+	Template string
+	Name     string
+}
+
+// Checkpoint records a call to Checkpoint or Rollback.
+type Checkpoint struct {
+	Database string
+	Name     string
+}
+
+// Reset records a call to ResetDatabase.
+type Reset struct {
+	Database string
+	Template string
+	Strategy databaseprovider.ResetStrategy
+}
+
+// Verify records a call to VerifyDatabase.
+type Verify struct {
+	Database string
+	Template string
+	Strategy databaseprovider.ResetStrategy
+}
+
+// Retain records a call to RetainDatabase.
+type Retain struct {
+	Database string
+	Retained string
+}
+
 // DatabaseProvider is a fake database provider that returns whatever you tell it.
+//
+// It may be called concurrently, but you should only inspect the call lists
+// once the calls are done.
 type DatabaseProvider struct {
+	mu sync.Mutex // serializes the calls
+
 	CreateList []string // A list of all calls to CreateDatabase.
 	CreateErr  error
 
 	DropList []string // A list of all calls to DropDatabase.
 	DropErr  error
 
+	TemplateList []string // A list of all calls to CreateTemplate.
+	TemplateErr  error
+
+	CloneList []Clone // A list of all calls to CloneDatabase.
+	CloneErr  error
+
+	// A list of all calls to ResetDatabase. A reset with the DropAndCreate
+	// strategy also drops and creates or clones the database, like a real
+	// provider would, and fails like those.
+	ResetList []Reset
+	ResetErr  error
+
+	CheckpointList []Checkpoint // A list of all calls to Checkpoint.
+	CheckpointErr  error
+
+	RollbackList []Checkpoint // A list of all calls to Rollback.
+	RollbackErr  error
+
+	DropCheckpointsList []string // A list of all calls to DropCheckpoints.
+	DropCheckpointsErr  error
+
+	RetainList []Retain // A list of all calls to RetainDatabase.
+	RetainErr  error
+
+	CreateUsersList []string // A list of all calls to CreateUsers, which returns Info.
+	CreateUsersErr  error
+
+	DropUsersList []string // A list of all calls to DropUsers.
+	DropUsersErr  error
+
+	DumpList []string // A list of all calls to DumpDatabase.
+	DumpErr  error
+	Dump     string // What DumpDatabase writes.
+
+	ListList  []string // A list of all calls to ListDatabases, by prefix.
+	ListErr   error
+	Databases []string // What ListDatabases lists, if they have the prefix.
+
+	PingErr error // What Ping returns.
+
+	VerifyList []Verify // A list of all calls to VerifyDatabase.
+	VerifyErr  error
+
 	Info pb.ConnectionInfo
 }
 
 func (p *DatabaseProvider) CreateDatabase(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.CreateList = append(p.CreateList, name)
 	return p.CreateErr
 }
 
 func (p *DatabaseProvider) DropDatabase(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.DropList = append(p.DropList, name)
 	return p.DropErr
 }
 
+func (p *DatabaseProvider) CreateTemplate(ctx context.Context, name, schema string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.TemplateList = append(p.TemplateList, name)
+	return p.TemplateErr
+}
+
+func (p *DatabaseProvider) CloneDatabase(ctx context.Context, template, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.CloneList = append(p.CloneList, Clone{Template: template, Name: name})
+	return p.CloneErr
+}
+
+func (p *DatabaseProvider) ResetDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	p.mu.Lock()
+	p.ResetList = append(p.ResetList, Reset{Database: database, Template: template, Strategy: strategy})
+	err := p.ResetErr
+	p.mu.Unlock()
+	if err != nil || strategy != databaseprovider.DropAndCreate {
+		return err
+	}
+	if err := p.DropDatabase(ctx, database); err != nil {
+		return err
+	}
+	if template == "" {
+		return p.CreateDatabase(ctx, database)
+	}
+	return p.CloneDatabase(ctx, template, database)
+}
+
+func (p *DatabaseProvider) Checkpoint(ctx context.Context, database, checkpoint string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.CheckpointList = append(p.CheckpointList, Checkpoint{Database: database, Name: checkpoint})
+	return p.CheckpointErr
+}
+
+func (p *DatabaseProvider) Rollback(ctx context.Context, database, checkpoint string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.RollbackList = append(p.RollbackList, Checkpoint{Database: database, Name: checkpoint})
+	return p.RollbackErr
+}
+
+func (p *DatabaseProvider) DropCheckpoints(ctx context.Context, database string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.DropCheckpointsList = append(p.DropCheckpointsList, database)
+	return p.DropCheckpointsErr
+}
+
+func (p *DatabaseProvider) RetainDatabase(ctx context.Context, database, retained string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.RetainList = append(p.RetainList, Retain{Database: database, Retained: retained})
+	return p.RetainErr
+}
+
+func (p *DatabaseProvider) CreateUsers(ctx context.Context, database string) (*pb.ConnectionInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.CreateUsersList = append(p.CreateUsersList, database)
+	if p.CreateUsersErr != nil {
+		return nil, p.CreateUsersErr
+	}
+	return &p.Info, nil
+}
+
+func (p *DatabaseProvider) DropUsers(ctx context.Context, database string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.DropUsersList = append(p.DropUsersList, database)
+	return p.DropUsersErr
+}
+
+func (p *DatabaseProvider) DumpDatabase(ctx context.Context, database string, w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.DumpList = append(p.DumpList, database)
+	if p.DumpErr != nil {
+		return p.DumpErr
+	}
+	_, err := io.WriteString(w, p.Dump)
+	return err
+}
+
+func (p *DatabaseProvider) ListDatabases(ctx context.Context, prefix string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ListList = append(p.ListList, prefix)
+	if p.ListErr != nil {
+		return nil, p.ListErr
+	}
+	var ret []string
+	for _, name := range p.Databases {
+		if strings.HasPrefix(name, prefix) {
+			ret = append(ret, name)
+		}
+	}
+	return ret, nil
+}
+
+func (p *DatabaseProvider) VerifyDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.VerifyList = append(p.VerifyList, Verify{Database: database, Template: template, Strategy: strategy})
+	return p.VerifyErr
+}
+
+func (p *DatabaseProvider) Ping(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.PingErr
+}
+
 func (p *DatabaseProvider) GetConnectionInfo(database string) *pb.ConnectionInfo {
 	return &p.Info
 }
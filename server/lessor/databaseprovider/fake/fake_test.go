@@ -3,6 +3,7 @@ package fake
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/go-test/deep"
@@ -14,13 +15,25 @@ import (
 // Make sure the fake always implements the provider interface.
 func TestSatisfiesInterface(t *testing.T) {
 	func(databaseprovider.DatabaseProvider) {}(&DatabaseProvider{})
+	func(databaseprovider.Pinger) {}(&DatabaseProvider{})
+	func(databaseprovider.Verifier) {}(&DatabaseProvider{})
 }
 
 func TestDatabaseProvider(t *testing.T) {
 	p := DatabaseProvider{
-		CreateErr: errors.New("create"),
-		DropErr:   errors.New("drop"),
-		Info:      pb.ConnectionInfo{},
+		CreateErr:   errors.New("create"),
+		DropErr:     errors.New("drop"),
+		TemplateErr: errors.New("template"),
+		CloneErr:    errors.New("clone"),
+
+		CheckpointErr:      errors.New("checkpoint"),
+		RollbackErr:        errors.New("rollback"),
+		DropCheckpointsErr: errors.New("drop checkpoints"),
+		RetainErr:          errors.New("retain"),
+		DumpErr:            errors.New("dump"),
+		CreateUsersErr:     errors.New("create users"),
+		DropUsersErr:       errors.New("drop users"),
+		Info:               pb.ConnectionInfo{},
 	}
 	ctx := context.Background()
 
@@ -48,7 +61,133 @@ func TestDatabaseProvider(t *testing.T) {
 		t.Fatal(diff)
 	}
 
+	if got, want := p.CreateTemplate(ctx, "tmpl", "CREATE TABLE foo (id INT)"), p.TemplateErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if diff := deep.Equal(p.TemplateList, []string{"tmpl"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if got, want := p.CloneDatabase(ctx, "tmpl", name1), p.CloneErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if diff := deep.Equal(p.CloneList, []Clone{{Template: "tmpl", Name: name1}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if got, want := p.Checkpoint(ctx, name1, "setup"), p.CheckpointErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if got, want := p.Rollback(ctx, name1, "setup"), p.RollbackErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if got, want := p.DropCheckpoints(ctx, name1), p.DropCheckpointsErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	want := []Checkpoint{{Database: name1, Name: "setup"}}
+	if diff := deep.Equal(p.CheckpointList, want); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.RollbackList, want); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.DropCheckpointsList, []string{name1}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if got, want := p.RetainDatabase(ctx, name1, "kept"), p.RetainErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if diff := deep.Equal(p.RetainList, []Retain{{Database: name1, Retained: "kept"}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if _, err := p.CreateUsers(ctx, name1); err != p.CreateUsersErr {
+		t.Fatalf("Got error %q, want %q", err, p.CreateUsersErr)
+	}
+	if got, want := p.DropUsers(ctx, name1), p.DropUsersErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if diff := deep.Equal(p.CreateUsersList, []string{name1}); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.DropUsersList, []string{name1}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if got, want := p.DumpDatabase(ctx, name1, io.Discard), p.DumpErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if diff := deep.Equal(p.DumpList, []string{name1}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	p.Databases = []string{"testserver_db_0", "other"}
+	got, err := p.ListDatabases(ctx, "testserver_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(got, []string{"testserver_db_0"}); diff != nil {
+		t.Fatal(diff)
+	}
+	p.ListErr = errors.New("list")
+	if _, err := p.ListDatabases(ctx, "testserver_"); err != p.ListErr {
+		t.Fatalf("Got error %q, want %q", err, p.ListErr)
+	}
+	if diff := deep.Equal(p.ListList, []string{"testserver_", "testserver_"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	p.VerifyErr = errors.New("verify")
+	if got, want := p.VerifyDatabase(ctx, name1, "tmpl", databaseprovider.Truncate), p.VerifyErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if diff := deep.Equal(p.VerifyList, []Verify{{Database: name1, Template: "tmpl", Strategy: databaseprovider.Truncate}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	p.PingErr = errors.New("ping")
+	if got, want := p.Ping(ctx), p.PingErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+
 	if got, want := p.GetConnectionInfo(name1), &p.Info; got != want {
 		t.Fatalf("Got %v, want %v", got, want)
 	}
 }
+
+func TestResetDatabase(t *testing.T) {
+	p := DatabaseProvider{}
+	ctx := context.Background()
+	for _, r := range []Reset{
+		{Database: "a", Strategy: databaseprovider.Truncate},
+		{Database: "a", Template: "tmpl", Strategy: databaseprovider.RestoreTemplate},
+		{Database: "a", Strategy: databaseprovider.DropAndCreate},
+		{Database: "a", Template: "tmpl", Strategy: databaseprovider.DropAndCreate},
+	} {
+		if err := p.ResetDatabase(ctx, r.Database, r.Template, r.Strategy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := len(p.ResetList), 4; got != want {
+		t.Fatalf("Got %d resets, want %d", got, want)
+	}
+	// Only the resets that drop and create the database do so.
+	if diff := deep.Equal(p.DropList, []string{"a", "a"}); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.CreateList, []string{"a"}); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.CloneList, []Clone{{Template: "tmpl", Name: "a"}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	p.ResetErr = errors.New("reset")
+	if got, want := p.ResetDatabase(ctx, "a", "", databaseprovider.DropAndCreate), p.ResetErr; got != want {
+		t.Fatalf("Got error %q, want %q", got, want)
+	}
+	if got, want := len(p.DropList), 2; got != want {
+		t.Fatalf("Got %d drops, want %d", got, want)
+	}
+}
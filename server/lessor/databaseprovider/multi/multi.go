@@ -0,0 +1,604 @@
+// Package multi implements a database provider that spreads the databases
+// over several backend providers, e.g. one per MySQL server, and takes the
+// backends that are down out of rotation.
+package multi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// How long to wait for a backend to answer a health check.
+const pingTimeout = 5 * time.Second
+
+// Backend is one of the providers over which the databases are spread.
+type Backend struct {
+	// Name identifies the backend in logs and status.
+	Name string
+
+	Provider databaseprovider.DatabaseProvider
+
+	// Weight is the backend's share of the databases relative to the other
+	// backends, e.g. a backend of weight 2 gets twice as many databases as
+	// one of weight 1. Zero means 1.
+	Weight int
+
+	// Err, if set, keeps the backend out of rotation from the start, e.g.
+	// because it was unreachable, until a health check finds it healthy.
+	Err error
+}
+
+// BackendStatus is a snapshot of the state of a backend.
+type BackendStatus struct {
+	Name      string
+	Healthy   bool
+	Databases int    // how many databases are placed on the backend
+	Error     string // why the backend is unhealthy
+}
+
+// Provider places every new database on the healthy backend with the lowest
+// load relative to its weight, and forwards the calls about a database to the
+// backend where it was placed. Templates are created on each backend when a
+// database is first cloned from them there.
+type Provider struct {
+	backends []*backend    // const
+	changed  chan struct{} // receives when a backend becomes unhealthy
+	tmplMu   sync.Mutex    // serializes the creation of templates on other backends
+
+	mu        sync.Mutex          // guards the members below and those of the backends
+	placed    map[string]*backend // the backend of each database, by name
+	templates map[string]string   // the schemas of the templates, by name
+
+	// Which databases to drop from the backends that were down when the pool
+	// reclaimed its leftovers, once they're up. Nil until the pool says.
+	reclaimPrefix string
+	reclaimOwned  func(name string) bool
+}
+
+type backend struct {
+	Backend
+	weight    int
+	healthy   bool
+	err       error
+	databases int             // how many databases are placed on it
+	templates map[string]bool // the templates that exist on it
+	orphans   []string        // databases to drop before it's used again
+	leftovers bool            // whether it may have leftovers of a previous run that weren't listed
+}
+
+// New creates a provider over the backends, which are presumed healthy unless
+// they have an error.
+func New(backends ...Backend) *Provider {
+	m := &Provider{
+		changed:   make(chan struct{}, 1),
+		placed:    make(map[string]*backend),
+		templates: make(map[string]string),
+	}
+	for _, b := range backends {
+		w := b.Weight
+		if w <= 0 {
+			w = 1
+		}
+		m.backends = append(m.backends, &backend{
+			Backend:   b,
+			weight:    w,
+			healthy:   b.Err == nil,
+			err:       b.Err,
+			leftovers: b.Err != nil,
+			templates: make(map[string]bool),
+		})
+	}
+	return m
+}
+
+// Returns the healthy backend with the lowest load relative to its weight.
+func (m *Provider) pickLocked() (*backend, error) {
+	var best *backend
+	for _, b := range m.backends {
+		if !b.healthy {
+			continue
+		}
+		if best == nil || (b.databases+1)*best.weight < (best.databases+1)*b.weight {
+			best = b
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no healthy backend")
+	}
+	return best, nil
+}
+
+// Returns the backend where the database is, or places it on one.
+func (m *Provider) place(name string) (*backend, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b := m.placed[name]; b != nil {
+		return b, nil
+	}
+	b, err := m.pickLocked()
+	if err != nil {
+		return nil, err
+	}
+	m.placeLocked(name, b)
+	return b, nil
+}
+
+func (m *Provider) placeLocked(name string, b *backend) {
+	m.placed[name] = b
+	b.databases++
+}
+
+func (m *Provider) unplaceLocked(name string) {
+	if b := m.placed[name]; b != nil {
+		b.databases--
+		delete(m.placed, name)
+	}
+}
+
+// Returns the backend where the database is, or nil if it's nowhere, and
+// whether the backend is healthy.
+func (m *Provider) lookup(name string) (*backend, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.placed[name]
+	return b, b != nil && b.healthy
+}
+
+// Returns the healthy backend where the database is.
+func (m *Provider) find(name string) (*backend, error) {
+	b, healthy := m.lookup(name)
+	if b == nil {
+		return nil, fmt.Errorf("database %s is not on any backend", name)
+	}
+	if !healthy {
+		return nil, fmt.Errorf("database %s is on unavailable backend %s", name, b.Name)
+	}
+	return b, nil
+}
+
+func (m *Provider) CreateDatabase(ctx context.Context, name string) error {
+	b, err := m.place(name)
+	if err != nil {
+		return err
+	}
+	return b.Provider.CreateDatabase(ctx, name)
+}
+
+// DropDatabase drops the database from its backend, or from every backend
+// if it's a template. The databases on unhealthy backends are forgotten, and
+// dropped once the backend is healthy again.
+func (m *Provider) DropDatabase(ctx context.Context, name string) error {
+	m.mu.Lock()
+	var targets []*backend
+	if _, ok := m.templates[name]; ok {
+		for _, b := range m.backends {
+			if b.templates[name] {
+				targets = append(targets, b)
+			}
+		}
+	} else if b := m.placed[name]; b != nil {
+		targets = append(targets, b)
+	}
+	var healthy []*backend
+	for _, b := range targets {
+		if b.healthy {
+			healthy = append(healthy, b)
+		} else {
+			b.orphans = append(b.orphans, name)
+			m.forgetLocked(name, b)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, b := range healthy {
+		if err := b.Provider.DropDatabase(ctx, name); err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.forgetLocked(name, b)
+		m.mu.Unlock()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.templates, name)
+	return nil
+}
+
+// Forgets that the database is on the backend.
+func (m *Provider) forgetLocked(name string, b *backend) {
+	delete(b.templates, name)
+	if m.placed[name] == b {
+		m.unplaceLocked(name)
+	}
+}
+
+// CreateTemplate creates the template on one backend, and remembers the schema
+// to create it on the others when needed.
+func (m *Provider) CreateTemplate(ctx context.Context, name, schema string) error {
+	m.mu.Lock()
+	b, err := m.pickLocked()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	// The template may be left over on the backend, e.g. from before it was
+	// unhealthy.
+	if err := b.Provider.DropDatabase(ctx, name); err != nil {
+		return err
+	}
+	if err := b.Provider.CreateTemplate(ctx, name, schema); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.templates[name] = schema
+	b.templates[name] = true
+	return nil
+}
+
+func (m *Provider) CloneDatabase(ctx context.Context, template, name string) error {
+	b, err := m.place(name)
+	if err != nil {
+		return err
+	}
+	if err := m.ensureTemplate(ctx, b, template); err != nil {
+		return err
+	}
+	return b.Provider.CloneDatabase(ctx, template, name)
+}
+
+// Creates the template on the backend, unless it's there already.
+func (m *Provider) ensureTemplate(ctx context.Context, b *backend, template string) error {
+	m.tmplMu.Lock()
+	defer m.tmplMu.Unlock()
+	m.mu.Lock()
+	schema, ok := m.templates[template]
+	exists := b.templates[template]
+	m.mu.Unlock()
+	if exists {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("unknown template %s", template)
+	}
+	glog.V(1).Infof("Creating template %s on backend %s", template, b.Name)
+	if err := b.Provider.DropDatabase(ctx, template); err != nil {
+		return err
+	}
+	if err := b.Provider.CreateTemplate(ctx, template, schema); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b.templates[template] = true
+	return nil
+}
+
+// ResetDatabase resets the database on its backend. If the strategy is
+// DropAndCreate, or the backend is unhealthy, the database is placed again
+// as if it were new, which may move it to another backend.
+func (m *Provider) ResetDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	b, healthy := m.lookup(database)
+	if b == nil || !healthy || strategy == databaseprovider.DropAndCreate {
+		if err := m.DropDatabase(ctx, database); err != nil {
+			return err
+		}
+		if template == "" {
+			return m.CreateDatabase(ctx, database)
+		}
+		return m.CloneDatabase(ctx, template, database)
+	}
+	if template != "" {
+		if err := m.ensureTemplate(ctx, b, template); err != nil {
+			return err
+		}
+	}
+	return b.Provider.ResetDatabase(ctx, database, template, strategy)
+}
+
+// VerifyDatabase verifies the database on its backend, if the backend can.
+func (m *Provider) VerifyDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	b, err := m.find(database)
+	if err != nil {
+		return err
+	}
+	if v, ok := b.Provider.(databaseprovider.Verifier); ok {
+		return v.VerifyDatabase(ctx, database, template, strategy)
+	}
+	return nil
+}
+
+func (m *Provider) Checkpoint(ctx context.Context, database, checkpoint string) error {
+	b, err := m.find(database)
+	if err != nil {
+		return err
+	}
+	return b.Provider.Checkpoint(ctx, database, checkpoint)
+}
+
+func (m *Provider) Rollback(ctx context.Context, database, checkpoint string) error {
+	b, err := m.find(database)
+	if err != nil {
+		return err
+	}
+	return b.Provider.Rollback(ctx, database, checkpoint)
+}
+
+// DropCheckpoints drops the database's checkpoints, unless the database is
+// nowhere or on an unhealthy backend, in which case they are dropped along
+// with the database.
+func (m *Provider) DropCheckpoints(ctx context.Context, database string) error {
+	if b, healthy := m.lookup(database); healthy {
+		return b.Provider.DropCheckpoints(ctx, database)
+	}
+	return nil
+}
+
+// RetainDatabase retains the database on the same backend.
+func (m *Provider) RetainDatabase(ctx context.Context, database, retained string) error {
+	b, err := m.find(database)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.placeLocked(retained, b)
+	m.mu.Unlock()
+	return b.Provider.RetainDatabase(ctx, database, retained)
+}
+
+func (m *Provider) DumpDatabase(ctx context.Context, database string, w io.Writer) error {
+	b, err := m.find(database)
+	if err != nil {
+		return err
+	}
+	return b.Provider.DumpDatabase(ctx, database, w)
+}
+
+func (m *Provider) CreateUsers(ctx context.Context, database string) (*pb.ConnectionInfo, error) {
+	b, err := m.find(database)
+	if err != nil {
+		return nil, err
+	}
+	return b.Provider.CreateUsers(ctx, database)
+}
+
+// DropUsers drops the database's users, unless the database is nowhere or on
+// an unhealthy backend, in which case they are dropped along with the database.
+func (m *Provider) DropUsers(ctx context.Context, database string) error {
+	if b, healthy := m.lookup(database); healthy {
+		return b.Provider.DropUsers(ctx, database)
+	}
+	return nil
+}
+
+// ListDatabases lists the databases with the prefix on all the healthy
+// backends, and remembers where they are so they can be dropped. It fails
+// only if no backend could list them. The backends that are down are listed
+// by CheckHealth once they're up, if the pool asked with ReclaimLater.
+func (m *Provider) ListDatabases(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	var healthy []*backend
+	for _, b := range m.backends {
+		if b.healthy {
+			healthy = append(healthy, b)
+		}
+	}
+	m.mu.Unlock()
+
+	var ret []string
+	var lastErr error
+	listed := 0
+	for _, b := range healthy {
+		names, err := b.Provider.ListDatabases(ctx, prefix)
+		if err != nil {
+			glog.Warningf("Error listing the databases on backend %s: %s", b.Name, err)
+			lastErr = err
+			continue
+		}
+		listed++
+		m.mu.Lock()
+		b.leftovers = false
+		for _, name := range names {
+			if m.placed[name] == nil {
+				m.placeLocked(name, b)
+			}
+		}
+		m.mu.Unlock()
+		ret = append(ret, names...)
+	}
+	if listed == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// GetConnectionInfo tells how to connect to the database on its backend.
+func (m *Provider) GetConnectionInfo(database string) *pb.ConnectionInfo {
+	b, _ := m.lookup(database)
+	if b == nil {
+		b = m.backends[0]
+	}
+	return b.Provider.GetConnectionInfo(database)
+}
+
+// ReclaimLater makes CheckHealth drop the pool's databases left over from a
+// previous run on the backends that were down when the pool listed them,
+// before those backends join the rotation.
+func (m *Provider) ReclaimLater(prefix string, owned func(name string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reclaimPrefix, m.reclaimOwned = prefix, owned
+}
+
+// Available tells whether the database is on a healthy backend, or doesn't
+// exist yet.
+func (m *Provider) Available(database string) bool {
+	b, healthy := m.lookup(database)
+	return b == nil || healthy
+}
+
+// AvailabilityChanged returns a channel that receives when a backend becomes
+// unhealthy.
+func (m *Provider) AvailabilityChanged() <-chan struct{} {
+	return m.changed
+}
+
+// Monitor checks the health of the backends at the interval, until the
+// context is done.
+func (m *Provider) Monitor(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.CheckHealth(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckHealth pings the backends that can be pinged, and takes those that
+// don't answer out of rotation. The databases on a backend that becomes
+// healthy again may have been lost, so those that were forgotten meanwhile
+// are dropped, and so are the templates, which are created again when needed.
+// The backend only joins the rotation once they're all dropped, so that no
+// new database with the same name is placed on it first. The same goes for the
+// pool's leftovers of a previous run on a backend that was down when the pool
+// reclaimed them.
+func (m *Provider) CheckHealth(ctx context.Context) {
+	for _, b := range m.backends {
+		p, ok := b.Provider.(databaseprovider.Pinger)
+		if !ok {
+			continue
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := p.Ping(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.mu.Lock()
+		wasHealthy := b.healthy
+		var orphans []string
+		var prefix string
+		var owned func(string) bool
+		switch {
+		case wasHealthy && err != nil:
+			glog.Errorf("Backend %s is unhealthy, taking it out of rotation: %s", b.Name, err)
+			b.healthy, b.err = false, err
+			for name := range b.templates {
+				b.orphans = append(b.orphans, name)
+			}
+			b.templates = make(map[string]bool)
+			select {
+			case m.changed <- struct{}{}:
+			default:
+			}
+		case !wasHealthy && err != nil:
+			b.err = err
+		case !wasHealthy:
+			orphans, b.orphans = b.orphans, nil
+			if b.leftovers {
+				prefix, owned = m.reclaimPrefix, m.reclaimOwned
+			}
+		}
+		m.mu.Unlock()
+		if wasHealthy || err != nil {
+			continue
+		}
+
+		if owned != nil {
+			leftovers, err := m.listLeftovers(ctx, b, prefix, owned)
+			m.mu.Lock()
+			if err != nil {
+				b.err = err
+				b.orphans = append(b.orphans, orphans...)
+				m.mu.Unlock()
+				continue
+			}
+			b.leftovers = false
+			m.mu.Unlock()
+			orphans = append(orphans, leftovers...)
+		}
+		m.dropOrphans(ctx, b, orphans)
+		m.mu.Lock()
+		if len(b.orphans) == 0 {
+			glog.Infof("Backend %s is healthy again", b.Name)
+			b.healthy, b.err = true, nil
+		} else {
+			b.err = fmt.Errorf("%d databases left to drop before the backend is used again", len(b.orphans))
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Lists the pool's databases on the backend that were left over from a
+// previous run.
+func (m *Provider) listLeftovers(ctx context.Context, b *backend, prefix string, owned func(string) bool) ([]string, error) {
+	names, err := b.Provider.ListDatabases(ctx, prefix)
+	if err != nil {
+		glog.Errorf("Error listing the leftover databases on backend %s: %s", b.Name, err)
+		return nil, err
+	}
+	var ret []string
+	for _, name := range names {
+		if owned(name) {
+			ret = append(ret, name)
+		}
+	}
+	if len(ret) > 0 {
+		glog.Warningf("Dropping %d databases left over from a previous run on backend %s: %q", len(ret), b.Name, ret)
+	}
+	return ret, nil
+}
+
+// Drops the databases that were forgotten while the backend was unhealthy,
+// and keeps those that failed to drop for next time.
+func (m *Provider) dropOrphans(ctx context.Context, b *backend, names []string) {
+	var failed []string
+	for _, name := range names {
+		err := b.Provider.DropUsers(ctx, name)
+		if err == nil {
+			err = b.Provider.DropCheckpoints(ctx, name)
+		}
+		if err == nil {
+			err = b.Provider.DropDatabase(ctx, name)
+		}
+		if err != nil {
+			glog.Errorf("Error dropping database %s on backend %s: %s", name, b.Name, err)
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		b.orphans = append(b.orphans, failed...)
+	}
+}
+
+// Status returns the state of each backend, in the order they were given.
+func (m *Provider) Status() []BackendStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ret []BackendStatus
+	for _, b := range m.backends {
+		s := BackendStatus{Name: b.Name, Healthy: b.healthy, Databases: b.databases}
+		if b.err != nil {
+			s.Error = b.err.Error()
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
@@ -0,0 +1,369 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// Make sure the provider always implements the interfaces.
+func TestSatisfiesInterface(t *testing.T) {
+	func(databaseprovider.DatabaseProvider) {}(New())
+	func(databaseprovider.Availability) {}(New())
+	func(databaseprovider.Verifier) {}(New())
+	func(databaseprovider.Reclaimer) {}(New())
+}
+
+// Returns fake backends whose connection info tells them apart by address.
+func newBackends(weights ...int) ([]Backend, []*fake.DatabaseProvider) {
+	var backends []Backend
+	var fakes []*fake.DatabaseProvider
+	for i, w := range weights {
+		name := string(rune('a' + i))
+		f := &fake.DatabaseProvider{Info: pb.ConnectionInfo{
+			AppConn: &pb.ConnectionDetails{Address: name},
+		}}
+		backends = append(backends, Backend{Name: name, Provider: f, Weight: w})
+		fakes = append(fakes, f)
+	}
+	return backends, fakes
+}
+
+// Returns the name of the backend the database was placed on.
+func backendOf(m *Provider, database string) string {
+	return m.GetConnectionInfo(database).AppConn.Address
+}
+
+func TestWeightedPlacement(t *testing.T) {
+	backends, fakes := newBackends(1, 2)
+	m := New(backends...)
+	ctx := context.Background()
+	for _, name := range []string{"db_0", "db_1", "db_2", "db_3", "db_4", "db_5"} {
+		if err := m.CreateDatabase(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := deep.Equal(fakes[0].CreateList, []string{"db_1", "db_4"}); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(fakes[1].CreateList, []string{"db_0", "db_2", "db_3", "db_5"}); diff != nil {
+		t.Error(diff)
+	}
+
+	// The calls about a database go to its backend.
+	if _, err := m.CreateUsers(ctx, "db_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Checkpoint(ctx, "db_1", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RetainDatabase(ctx, "db_1", "db_1_retained"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backendOf(m, "db_1_retained"), "a"; got != want {
+		t.Errorf("Got retained database on backend %q, want %q", got, want)
+	}
+	if err := m.VerifyDatabase(ctx, "db_1", "", databaseprovider.Truncate); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakes[0].CreateUsersList) != 1 || len(fakes[0].CheckpointList) != 1 || len(fakes[0].VerifyList) != 1 || len(fakes[1].CreateUsersList) != 0 {
+		t.Error("Calls weren't forwarded to the database's backend")
+	}
+
+	// A database that's dropped is placed again when it's recreated, so the
+	// backends even out.
+	for _, name := range []string{"db_0", "db_2"} {
+		if err := m.DropDatabase(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := deep.Equal(m.Status(), []BackendStatus{
+		{Name: "a", Healthy: true, Databases: 3},
+		{Name: "b", Healthy: true, Databases: 2},
+	}); diff != nil {
+		t.Error(diff)
+	}
+	if err := m.CreateDatabase(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backendOf(m, "db_0"), "b"; got != want {
+		t.Errorf("Got database on backend %q, want %q", got, want)
+	}
+}
+
+func TestUnhealthyBackend(t *testing.T) {
+	backends, fakes := newBackends(1, 1)
+	m := New(backends...)
+	ctx := context.Background()
+	for _, name := range []string{"db_0", "db_1"} {
+		if err := m.CreateDatabase(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fakes[0].PingErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	select {
+	case <-m.AvailabilityChanged():
+	default:
+		t.Fatal("Got no notification of unavailability")
+	}
+	if m.Available("db_0") || !m.Available("db_1") {
+		t.Fatal("Got wrong availability")
+	}
+	if _, err := m.CreateUsers(ctx, "db_0"); err == nil {
+		t.Fatal("Got nil error leasing a database on an unhealthy backend")
+	}
+
+	// Resetting the database moves it to the healthy backend.
+	if err := m.DropUsers(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DropDatabase(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreateDatabase(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backendOf(m, "db_0"), "b"; got != want {
+		t.Errorf("Got database on backend %q, want %q", got, want)
+	}
+	if len(fakes[0].DropList) != 0 {
+		t.Errorf("Got drops %q on the unhealthy backend, want none", fakes[0].DropList)
+	}
+
+	// The leftover is dropped when the backend recovers.
+	fakes[0].PingErr = nil
+	m.CheckHealth(ctx)
+	if diff := deep.Equal(fakes[0].DropList, []string{"db_0"}); diff != nil {
+		t.Error(diff)
+	}
+	if diff := deep.Equal(fakes[0].DropUsersList, []string{"db_0"}); diff != nil {
+		t.Error(diff)
+	}
+
+	// No backend is healthy.
+	fakes[0].PingErr = errors.New("Oof!")
+	fakes[1].PingErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	if err := m.CreateDatabase(ctx, "db_2"); err == nil {
+		t.Fatal("Got nil error, want error")
+	}
+}
+
+// A backend that records whether it was in rotation while dropping databases.
+type droppingBackend struct {
+	*fake.DatabaseProvider
+	m       *Provider
+	healthy []bool
+}
+
+func (p *droppingBackend) DropDatabase(ctx context.Context, name string) error {
+	p.healthy = append(p.healthy, p.m.Status()[0].Healthy)
+	return p.DatabaseProvider.DropDatabase(ctx, name)
+}
+
+func TestRecoveryDropsOrphansFirst(t *testing.T) {
+	backends, fakes := newBackends(1, 1)
+	d := &droppingBackend{DatabaseProvider: fakes[0]}
+	backends[0].Provider = d
+	m := New(backends...)
+	d.m = m
+	ctx := context.Background()
+	if err := m.CreateDatabase(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+	fakes[0].PingErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	if err := m.DropDatabase(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The backend stays out of rotation until the orphan is dropped, so no
+	// database of the same name is placed on it and then dropped.
+	fakes[0].PingErr = nil
+	fakes[0].DropErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	if st := m.Status()[0]; st.Healthy {
+		t.Fatalf("Got status %+v, want unhealthy while the orphan is left", st)
+	}
+	fakes[0].DropErr = nil
+	m.CheckHealth(ctx)
+	if st := m.Status()[0]; !st.Healthy {
+		t.Fatalf("Got status %+v, want healthy", st)
+	}
+	if diff := deep.Equal(d.healthy, []bool{false, false}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestUnhealthyAtStart(t *testing.T) {
+	backends, fakes := newBackends(1, 1)
+	backends[0].Err = errors.New("Oof!")
+	m := New(backends...)
+	ctx := context.Background()
+	if err := m.CreateDatabase(ctx, "db_0"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backendOf(m, "db_0"), "b"; got != want {
+		t.Errorf("Got database on backend %q, want %q", got, want)
+	}
+	if st := m.Status()[0]; st.Healthy || st.Error != "Oof!" {
+		t.Errorf("Got status %+v, want unhealthy", st)
+	}
+
+	// The backend joins the rotation once it answers.
+	m.CheckHealth(ctx)
+	if err := m.CreateDatabase(ctx, "db_1"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(fakes[0].CreateList, []string{"db_1"}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestReclaimLater(t *testing.T) {
+	backends, fakes := newBackends(1, 1)
+	backends[0].Err = errors.New("Oof!")
+	fakes[0].Databases = []string{"testserver_db_0", "testserver_db_0_retained_1", "testserver_other"}
+	fakes[1].Databases = []string{"testserver_db_1"}
+	m := New(backends...)
+	ctx := context.Background()
+	m.ReclaimLater("testserver_", func(name string) bool { return strings.HasPrefix(name, "testserver_db_") })
+	if _, err := m.ListDatabases(ctx, "testserver_"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The leftovers on the backend that was down are dropped when it's up,
+	// unless it can't list them.
+	fakes[0].ListErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	if st := m.Status()[0]; st.Healthy || len(fakes[0].DropList) != 0 {
+		t.Fatalf("Got status %+v and drops %q, want unhealthy and none", st, fakes[0].DropList)
+	}
+	fakes[0].ListErr = nil
+	m.CheckHealth(ctx)
+	if diff := deep.Equal(fakes[0].DropList, []string{"testserver_db_0", "testserver_db_0_retained_1"}); diff != nil {
+		t.Fatal(diff)
+	}
+	if st := m.Status()[0]; !st.Healthy {
+		t.Fatalf("Got status %+v, want healthy", st)
+	}
+
+	// It's only done once, and not on the backends that were listed.
+	fakes[0].PingErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	fakes[0].PingErr = nil
+	m.CheckHealth(ctx)
+	if got, want := len(fakes[0].ListList), 2; got != want {
+		t.Fatalf("Got %d listings, want %d", got, want)
+	}
+	if len(fakes[1].DropList) != 0 {
+		t.Fatalf("Got drops %q on the listed backend, want none", fakes[1].DropList)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	backends, fakes := newBackends(1, 1)
+	m := New(backends...)
+	ctx := context.Background()
+	if err := m.CreateTemplate(ctx, "tmpl", "CREATE TABLE foo (id INT)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"db_0", "db_1", "db_2"} {
+		if err := m.CloneDatabase(ctx, "tmpl", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The template is created on the second backend on its first clone.
+	for i, f := range fakes {
+		if diff := deep.Equal(f.TemplateList, []string{"tmpl"}); diff != nil {
+			t.Errorf("Backend %d: %v", i, diff)
+		}
+	}
+
+	if err := m.DropDatabase(ctx, "tmpl"); err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range fakes {
+		if got, want := f.DropList[len(f.DropList)-1], "tmpl"; got != want {
+			t.Errorf("Backend %d: got last drop %q, want %q", i, got, want)
+		}
+	}
+	if err := m.CloneDatabase(ctx, "tmpl", "db_3"); err == nil {
+		t.Fatal("Got nil error cloning a dropped template")
+	}
+}
+
+func TestListDatabases(t *testing.T) {
+	backends, fakes := newBackends(1, 1, 1)
+	fakes[0].Databases = []string{"testserver_db_1", "other"}
+	fakes[1].Databases = []string{"testserver_db_0"}
+	fakes[2].ListErr = errors.New("Oof!")
+	m := New(backends...)
+	ctx := context.Background()
+	got, err := m.ListDatabases(ctx, "testserver_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(got, []string{"testserver_db_0", "testserver_db_1"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// The listed databases are dropped from where they are.
+	if err := m.DropDatabase(ctx, "testserver_db_0"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(fakes[1].DropList, []string{"testserver_db_0"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	fakes[0].ListErr = errors.New("Oof!")
+	fakes[1].ListErr = errors.New("Oof!")
+	if _, err := m.ListDatabases(ctx, "testserver_"); err == nil {
+		t.Fatal("Got nil error, want error")
+	}
+}
+
+func TestResetDatabase(t *testing.T) {
+	backends, fakes := newBackends(1, 1)
+	m := New(backends...)
+	ctx := context.Background()
+	if err := m.CreateTemplate(ctx, "tmpl", "CREATE TABLE foo (id INT)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"db_0", "db_1"} {
+		if err := m.CloneDatabase(ctx, "tmpl", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Resetting in place goes to the database's backend, which gets the template.
+	if err := m.ResetDatabase(ctx, "db_1", "tmpl", databaseprovider.RestoreTemplate); err != nil {
+		t.Fatal(err)
+	}
+	want := []fake.Reset{{Database: "db_1", Template: "tmpl", Strategy: databaseprovider.RestoreTemplate}}
+	if diff := deep.Equal(fakes[1].ResetList, want); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// Databases on an unhealthy backend are recreated elsewhere instead.
+	fakes[0].PingErr = errors.New("Oof!")
+	m.CheckHealth(ctx)
+	if err := m.ResetDatabase(ctx, "db_0", "tmpl", databaseprovider.Truncate); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backendOf(m, "db_0"), "b"; got != want {
+		t.Errorf("Got database on backend %q, want %q", got, want)
+	}
+	if len(fakes[0].ResetList) != 0 {
+		t.Errorf("Got resets %+v on the unhealthy backend, want none", fakes[0].ResetList)
+	}
+}
@@ -0,0 +1,98 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/glog"
+)
+
+// ErrDraining means the lessor has stopped granting leases, e.g. because the
+// server is shutting down.
+var ErrDraining = errors.New("the pool is draining")
+
+// Drain stops granting leases, and fails the requests that are waiting with
+// ErrDraining. The current leases are unaffected, but their holders should be
+// told to Return() them; see Draining().
+func (l *Lessor) Drain() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.draining {
+		return
+	}
+	glog.Infof("Draining pool %q with %d active leases", l.name, len(l.leases))
+	l.draining = true
+	close(l.drainCh)
+}
+
+// Draining returns a channel that is closed when the lessor starts draining.
+func (l *Lessor) Draining() <-chan struct{} {
+	return l.drainCh
+}
+
+// WaitDrained blocks until every lease has been returned, or the context has ended.
+func (l *Lessor) WaitDrained(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		n := len(l.leases)
+		l.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-l.endedCh:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Cleanup drops every database the lessor created: the pool, the templates,
+// and the retained and quarantined databases, along with the lessees' users
+// and checkpoints. Call it only once Run() has returned, so nothing is
+// recreating them meanwhile. It tries them all, and returns the first error.
+// The journal is deleted too, so any database that's left is dropped by the
+// next run.
+func (l *Lessor) Cleanup(ctx context.Context) error {
+	l.mu.Lock()
+	l.journal.remove()
+	l.journal = nil
+	var pool, retained, others []string
+	for name := range l.databases {
+		pool = append(pool, name)
+	}
+	for name := range l.quarantined {
+		pool = append(pool, name)
+	}
+	for name := range l.retained {
+		retained = append(retained, name)
+	}
+	for _, t := range l.templates {
+		others = append(others, t.name)
+	}
+	l.mu.Unlock()
+
+	glog.Infof("Dropping the %d databases of pool %q", len(pool)+len(retained)+len(others), l.name)
+	var first error
+	check := func(err error) {
+		if err != nil {
+			glog.Errorf("Error cleaning up pool %q: %s", l.name, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	for _, name := range pool {
+		check(l.provider.DropUsers(ctx, name))
+		check(l.provider.DropCheckpoints(ctx, name))
+		check(l.provider.DropDatabase(ctx, name))
+	}
+	for _, name := range retained {
+		check(l.provider.DropUsers(ctx, name))
+		check(l.provider.DropDatabase(ctx, name))
+	}
+	for _, name := range others {
+		check(l.provider.DropDatabase(ctx, name))
+	}
+	return first
+}
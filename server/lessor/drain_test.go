@@ -0,0 +1,89 @@
+package lessor
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestDrain(t *testing.T) {
+	les := New(&fake.DatabaseProvider{}, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	held, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting := startWaiting(ctx, les.Request(LeaseOptions{}))
+
+	les.Drain()
+	select {
+	case <-les.Draining():
+	default:
+		t.Fatal("Draining() is not closed")
+	}
+
+	// Those waiting, and those who come later, are turned away.
+	if res := <-waiting; res.err != ErrDraining {
+		t.Fatalf("Got error %v, want %v", res.err, ErrDraining)
+	}
+	if _, err := les.Lease(ctx); err != ErrDraining {
+		t.Fatalf("Got error %v, want %v", err, ErrDraining)
+	}
+
+	// The holder still has its lease, until it returns it.
+	shortCtx, cancelShort := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelShort()
+	if err := les.WaitDrained(shortCtx); err != context.DeadlineExceeded {
+		t.Fatalf("Got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	les.Return(held)
+	if err := les.WaitDrained(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		les.Run(ctx)
+	}()
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retained, err := les.ReturnAndRetain(ctx, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err = les.LeaseWithOptions(ctx, LeaseOptions{Schema: &Schema{SQL: "CREATE TABLE foo (id INT)"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	les.Return(l)
+	cancel()
+	<-runDone
+
+	p.DropList = nil
+	if err := les.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"testserver_db_0", "testserver_db_1", retained[0].Name, p.TemplateList[0]}
+	sort.Strings(want)
+	sort.Strings(p.DropList)
+	if diff := deep.Equal(p.DropList, want); diff != nil {
+		t.Fatal(diff)
+	}
+}
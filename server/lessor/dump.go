@@ -0,0 +1,39 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/glog"
+)
+
+// ErrNotLeased means the database is not one of the lease's databases.
+var ErrNotLeased = errors.New("database is not part of the lease")
+
+// Dump writes SQL statements that recreate the schema and data of one of the
+// leased databases, or the first one if the name is empty, e.g. to save as a CI
+// artifact. The lease can't be returned until the dump is done.
+//
+// Returns ErrNoSuchLease if the lease has ended, or ErrNotLeased if the
+// database is not one of the lease's.
+func (l *Lessor) Dump(ctx context.Context, lease Lease, database string, w io.Writer) error {
+	g, err := l.lockGrant(lease)
+	if err != nil {
+		return err
+	}
+	defer g.mu.Unlock()
+	if database == "" {
+		database = g.info.Database
+	}
+	found := false
+	for _, db := range g.info.Databases {
+		found = found || db == database
+	}
+	if !found {
+		return fmt.Errorf("%w: %q", ErrNotLeased, database)
+	}
+	glog.V(1).Infof("Lease %s is dumping database %s", g.info.ID, database)
+	return l.provider.DumpDatabase(ctx, database, w)
+}
@@ -0,0 +1,49 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestDump(t *testing.T) {
+	p := &fake.DatabaseProvider{Dump: "CREATE TABLE foo (id INT);\n"}
+	les := New(p, Config{Size: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.LeaseWithOptions(ctx, LeaseOptions{Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbs := les.Databases(l)
+
+	// Dumps the first database unless you name one.
+	var b strings.Builder
+	if err := les.Dump(ctx, l, "", &b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), p.Dump; got != want {
+		t.Fatalf("Got dump %q, want %q", got, want)
+	}
+	if err := les.Dump(ctx, l, dbs[1], &b); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(p.DumpList, dbs); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if err := les.Dump(ctx, l, "bogus", &b); !errors.Is(err, ErrNotLeased) {
+		t.Fatalf("Got error %v, want %v", err, ErrNotLeased)
+	}
+	les.Return(l)
+	if err := les.Dump(ctx, l, "", &b); err != ErrNoSuchLease {
+		t.Fatalf("Got error %v, want %v", err, ErrNoSuchLease)
+	}
+}
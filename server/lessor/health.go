@@ -0,0 +1,166 @@
+package lessor
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+)
+
+const (
+	// How many times a database is reset before it's quarantined, unless configured.
+	defaultResetAttempts = 5
+
+	// How long to wait before retrying a failed reset the first time, unless
+	// configured. The wait doubles with every failure, up to maxResetBackoff.
+	defaultResetBackoff = time.Second
+	maxResetBackoff     = time.Minute
+)
+
+// Quarantined is a database that was taken out of the pool because it kept
+// failing to reset. It's left on the server as it was, for investigation.
+type Quarantined struct {
+	// The name of the database.
+	Name string
+
+	// How many times it failed to reset, and the last error.
+	Attempts int
+	Error    string
+
+	// When it was quarantined.
+	Time time.Time
+}
+
+// Handles a failed reset of the database. It's retried with exponential
+// backoff, in case the server was only briefly unavailable, and a database
+// that keeps failing is quarantined and replaced with a fresh one, so the pool
+// doesn't shrink.
+func (l *Lessor) resetFailed(ctx context.Context, name string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	db := l.databases[name]
+	db.state = failed
+	db.failures++
+	backoff := l.backoff(db.failures)
+	if db.failures < l.resetAttempts {
+		glog.Warningf("Retrying reset of database %s in %s after error: %s", name, backoff, err)
+		go l.retryReset(ctx, name, backoff)
+		l.checkCapacityLocked()
+		return
+	}
+
+	glog.Errorf("Quarantining database %s after %d failed resets: %s", name, db.failures, err)
+	l.quarantineLocked(ctx, name, err, backoff)
+}
+
+// Takes the failed database out of the pool, and replaces it with a fresh one.
+// If the server itself is failing, the fresh database would fail too, so it's
+// created after the backoff rather than flooding the quarantine.
+func (l *Lessor) quarantineLocked(ctx context.Context, name string, err error, backoff time.Duration) {
+	db := l.databases[name]
+	delete(l.databases, name)
+	l.journalLocked(name)
+	l.quarantined[name] = Quarantined{
+		Name:     name,
+		Attempts: db.failures,
+		Error:    err.Error(),
+		Time:     l.clock.Now(),
+	}
+	fresh := l.addDatabaseLocked()
+	go l.retryReset(ctx, fresh, backoff)
+	l.checkCapacityLocked()
+}
+
+// Returns how long to wait after the nth failure in a row.
+func (l *Lessor) backoff(failures int) time.Duration {
+	backoff := l.resetBackoff << (failures - 1)
+	if backoff > maxResetBackoff || backoff <= 0 {
+		backoff = maxResetBackoff
+	}
+	return backoff
+}
+
+// Replaces the ready databases that become unavailable, e.g. when their server
+// goes down, so they aren't leased. The provider recreates them where it can.
+func (l *Lessor) availabilityWorker(ctx context.Context, a databaseprovider.Availability) {
+	for {
+		select {
+		case <-a.AvailabilityChanged():
+		case <-ctx.Done():
+			return
+		}
+		var names, rest []string
+		l.mu.Lock()
+		for _, name := range l.ready {
+			if a.Available(name) {
+				rest = append(rest, name)
+			} else {
+				names = append(names, name)
+				l.databases[name].state = resetting
+			}
+		}
+		l.ready = rest
+		l.mu.Unlock()
+		if len(names) > 0 {
+			glog.Warningf("Replacing %d unavailable databases of pool %q: %q", len(names), l.name, names)
+			l.resetAll(names)
+		}
+	}
+}
+
+// Hands the database to the reset workers after the backoff.
+func (l *Lessor) retryReset(ctx context.Context, name string, backoff time.Duration) {
+	t := l.clock.NewTimer(backoff)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		l.setState(name, resetting)
+		l.resetCh <- name
+	case <-ctx.Done():
+	}
+}
+
+// Returns how many databases are usable, i.e. didn't fail to reset since
+// they last succeeded.
+func (l *Lessor) capacityLocked() int {
+	capacity := 0
+	for _, db := range l.databases {
+		if db.failures == 0 {
+			capacity++
+		}
+	}
+	return capacity
+}
+
+// Raises the alarm when the capacity drops below the minimum, and lowers it
+// when the capacity is restored.
+func (l *Lessor) checkCapacityLocked() {
+	capacity := l.capacityLocked()
+	switch low := capacity < l.minCapacity; {
+	case low && !l.lowCapacity:
+		glog.Errorf("Pool %q is down to %d usable databases, below the minimum of %d", l.name, capacity, l.minCapacity)
+	case !low && l.lowCapacity:
+		glog.Infof("Pool %q is back to %d usable databases", l.name, capacity)
+	}
+	l.lowCapacity = capacity < l.minCapacity
+}
+
+// Quarantined lists the quarantined databases, in the order they were quarantined.
+func (l *Lessor) Quarantined() []Quarantined {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ret []Quarantined
+	for _, q := range l.quarantined {
+		ret = append(ret, q)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if !ret[i].Time.Equal(ret[j].Time) {
+			return ret[i].Time.Before(ret[j].Time)
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
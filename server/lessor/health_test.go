@@ -0,0 +1,141 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/multi"
+)
+
+// A provider that fails to create databases until told otherwise.
+type flakyProvider struct {
+	fake.DatabaseProvider
+
+	mu  sync.Mutex
+	err error
+}
+
+func (p *flakyProvider) CreateDatabase(ctx context.Context, name string) error {
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.DatabaseProvider.CreateDatabase(ctx, name)
+}
+
+func (p *flakyProvider) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Advances the clock until the condition holds, since the lessor's timers are
+// created asynchronously.
+func advanceUntil(t *testing.T, c *simulated.Clock, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out")
+		}
+		c.Advance(time.Second)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResetRetried(t *testing.T) {
+	p := &flakyProvider{err: errors.New("Oof!")}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	// Wait for the first failure, without letting the backoff expire.
+	deadline := time.Now().Add(5 * time.Second)
+	for les.Stats().Retrying == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Reset never failed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !les.Stats().LowCapacity {
+		t.Fatal("Got no low capacity, want the alarm raised")
+	}
+
+	// The server recovers, and so does the pool.
+	p.setErr(nil)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	st := les.Stats()
+	if st.LowCapacity || st.Failed != 0 || st.Retrying != 0 {
+		t.Fatalf("Got stats %+v, want a healthy pool", st)
+	}
+	if q := les.Quarantined(); len(q) != 0 {
+		t.Fatalf("Got quarantined %v, want none", q)
+	}
+}
+
+func TestResetQuarantined(t *testing.T) {
+	p := &flakyProvider{err: errors.New("Oof!")}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c, ResetAttempts: 2, MinCapacity: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	advanceUntil(t, c, func() bool { return len(les.Quarantined()) > 0 })
+	q := les.Quarantined()[0]
+	if q.Name != "testserver_db_0" || q.Attempts != 2 || q.Error != "Oof!" {
+		t.Fatalf("Got quarantined %+v, want testserver_db_0 after 2 attempts", q)
+	}
+
+	// The pool was refilled with a fresh database.
+	p.setErr(nil)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	if st := les.Stats(); st.Total != 1 || st.Failed != 1 || st.LowCapacity {
+		t.Fatalf("Got stats %+v, want 1 database and 1 quarantined", st)
+	}
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer les.Return(l)
+	if got, want := les.Database(l), "testserver_db_1"; got != want {
+		t.Fatalf("Got database %q, want %q", got, want)
+	}
+}
+
+func TestUnavailableDatabasesReplaced(t *testing.T) {
+	a, b := &fake.DatabaseProvider{}, &fake.DatabaseProvider{}
+	p := multi.New(multi.Backend{Name: "a", Provider: a}, multi.Backend{Name: "b", Provider: b})
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 2, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 2 })
+
+	// One database is on each backend, until one goes down.
+	a.PingErr = errors.New("Oof!")
+	p.CheckHealth(ctx)
+	advanceUntil(t, c, func() bool {
+		st := les.Stats()
+		return st.Ready == 2 && st.Unavailable == 0
+	})
+	if st := p.Status(); st[0].Databases != 0 || st[1].Databases != 2 {
+		t.Fatalf("Got backends %+v, want both databases on the healthy one", st)
+	}
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	les.Return(l)
+}
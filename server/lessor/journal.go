@@ -0,0 +1,214 @@
+package lessor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/glog"
+)
+
+// The states of the databases in the journal.
+const (
+	journalReady   = "ready"   // clean, and may be leased after a restart
+	journalLeased  = "leased"  // must be reset after a restart
+	journalDirty   = "dirty"   // being reset, so must be reset after a restart
+	journalRemoved = "removed" // no longer part of the pool
+)
+
+// The journal is compacted once it has this many more entries than the pool
+// has databases.
+const journalSlack = 1000
+
+// An entry of the journal, which records a change to the state of a database.
+type journalEntry struct {
+	Database string `json:"db"`
+	State    string `json:"state"`
+
+	// The template from which a ready database was cloned, if any.
+	Template string `json:"template,omitempty"`
+}
+
+// A journal records the membership of the pool and the state of its
+// databases in an append-only file, so that a restarted provider knows which
+// of the databases it left behind are clean. Any database that isn't known to
+// be clean is reset or dropped.
+type journal struct {
+	path    string
+	f       *os.File // nil once the journal is broken
+	entries int      // how many entries the file has
+}
+
+// Opens the journal at the path, and returns the last state of every database
+// in it. A missing journal is empty, and a torn entry at the end, e.g. after a
+// crash, is ignored.
+func openJournal(path string) (*journal, map[string]journalEntry, error) {
+	states := make(map[string]journalEntry)
+	entries := 0
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, nil, err
+	default:
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			var e journalEntry
+			if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+				glog.Warningf("Ignoring invalid entry in journal %s: %q", path, s.Text())
+				continue
+			}
+			entries++
+			if e.State == journalRemoved {
+				delete(states, e.Database)
+			} else {
+				states[e.Database] = e
+			}
+		}
+		f.Close()
+		if err := s.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &journal{path: path, f: f, entries: entries}, states, nil
+}
+
+// Appends the entry to the journal, and makes sure it's on disk. If that
+// fails, the journal is deleted, so the next run doesn't trust a database
+// whose state wasn't recorded.
+func (j *journal) record(e journalEntry) {
+	if j == nil || j.f == nil {
+		return
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = j.f.Write(append(b, '\n')); err == nil {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		glog.Errorf("Error writing journal %s, deleting it: %s", j.path, err)
+		j.remove()
+		return
+	}
+	j.entries++
+}
+
+// Replaces the journal with one entry per database, atomically.
+func (j *journal) compact(entries []journalEntry) {
+	if j == nil || j.f == nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err == nil {
+		w := bufio.NewWriter(tmp)
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err = enc.Encode(e); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err == nil {
+			err = tmp.Sync()
+		}
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), j.path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	var f *os.File
+	if err == nil {
+		f, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	}
+	if err != nil {
+		// The old journal is still valid, so keep appending to it.
+		glog.Errorf("Error compacting journal %s: %s", j.path, err)
+		return
+	}
+	j.f.Close()
+	j.f = f
+	j.entries = len(entries)
+}
+
+// Deletes the journal, after which nothing is recorded.
+func (j *journal) remove() {
+	if j == nil || j.f == nil {
+		return
+	}
+	j.f.Close()
+	j.f = nil
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		glog.Errorf("Error deleting journal %s: %s", j.path, err)
+	}
+}
+
+// Opens the configured journal, if any, and returns the last state of every
+// database in it. If it can't be read, no database is trusted.
+func (l *Lessor) openJournal() map[string]journalEntry {
+	if l.journalPath == "" {
+		return nil
+	}
+	j, states, err := openJournal(l.journalPath)
+	if err != nil {
+		glog.Errorf("Error opening journal %s of pool %q, running without it: %s", l.journalPath, l.name, err)
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.journal = j
+	return states
+}
+
+// Records the state of the database in the journal, if any.
+func (l *Lessor) journalLocked(name string) {
+	if l.journal == nil {
+		return
+	}
+	db, ok := l.databases[name]
+	if !ok {
+		l.journal.record(journalEntry{Database: name, State: journalRemoved})
+		return
+	}
+	l.journal.record(journalEntryOf(name, db))
+	if l.journal.entries > len(l.databases)+journalSlack {
+		l.compactJournalLocked()
+	}
+}
+
+// Replaces the journal with the current state of every database.
+func (l *Lessor) compactJournalLocked() {
+	if l.journal == nil {
+		return
+	}
+	var entries []journalEntry
+	for name, db := range l.databases {
+		entries = append(entries, journalEntryOf(name, db))
+	}
+	l.journal.compact(entries)
+}
+
+// Returns the entry that records the state of the database.
+func journalEntryOf(name string, db *database) journalEntry {
+	switch db.state {
+	case ready:
+		return journalEntry{Database: name, State: journalReady, Template: db.template}
+	case leased:
+		return journalEntry{Database: name, State: journalLeased}
+	default:
+		return journalEntry{Database: name, State: journalDirty}
+	}
+}
@@ -0,0 +1,153 @@
+package lessor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+// Runs the lessor until the returned function is called, which returns once
+// Run() has, like a crash of the provider.
+func runUntilCrash(les *Lessor) (crash func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		les.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Waits until the pool has been created.
+func waitStarted(t *testing.T, les *Lessor) {
+	deadline := time.Now().Add(5 * time.Second)
+	for les.Stats().Starting {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJournalRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.journal")
+	les := New(&fake.DatabaseProvider{}, Config{Size: 3, Journal: path})
+	crash := runUntilCrash(les)
+	waitStarted(t, les)
+	l, err := les.Lease(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	leased := les.Database(l)
+	crash()
+
+	// The server still has the databases, and some we don't know about.
+	p := &fake.DatabaseProvider{Databases: []string{
+		"testserver_db_0",
+		"testserver_db_1",
+		"testserver_db_2",
+		"testserver_db_9",
+		"testserver_db_1__ckpt_1234",
+	}}
+	les = New(p, Config{Size: 3, Journal: path})
+	crash = runUntilCrash(les)
+	defer crash()
+	waitStarted(t, les)
+
+	st := les.Stats()
+	if st.Recovered != 3 || st.Reclaimed != 2 || st.Total != 3 {
+		t.Fatalf("Got stats %+v, want 3 databases recovered and 2 reclaimed", st)
+	}
+	// Only the leased database was reset.
+	if diff := deep.Equal(p.CreateList, []string{leased}); diff != nil {
+		t.Fatal(diff)
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		l, err := les.Lease(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, les.Database(l))
+	}
+	sort.Strings(got)
+	if diff := deep.Equal(got, []string{"testserver_db_0", "testserver_db_1", "testserver_db_2"}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestJournalUnknownDatabasesDropped(t *testing.T) {
+	// Without a journal, nothing is trusted.
+	p := &fake.DatabaseProvider{Databases: []string{"testserver_db_0", "testserver_db_1"}}
+	les := New(p, Config{Size: 1, Journal: filepath.Join(t.TempDir(), "missing.journal")})
+	crash := runUntilCrash(les)
+	defer crash()
+	waitStarted(t, les)
+	if st := les.Stats(); st.Recovered != 0 || st.Reclaimed != 2 {
+		t.Fatalf("Got stats %+v, want 2 databases reclaimed", st)
+	}
+}
+
+func TestOpenJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.journal")
+	err := os.WriteFile(path, []byte(`{"db":"a","state":"ready"}
+{"db":"b","state":"leased"}
+{"db":"c","state":"ready","template":"t"}
+{"db":"c","state":"removed"}
+{"db":"a","state":"lea`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, states, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.remove()
+	if diff := deep.Equal(states, map[string]journalEntry{
+		"a": {Database: "a", State: journalReady},
+		"b": {Database: "b", State: journalLeased},
+	}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	j.compact([]journalEntry{{Database: "b", State: journalDirty}})
+	j.record(journalEntry{Database: "d", State: journalReady})
+	j.f.Close()
+	_, states, err = openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(states, map[string]journalEntry{
+		"b": {Database: "b", State: journalDirty},
+		"d": {Database: "d", State: journalReady},
+	}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestCleanupRemovesJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.journal")
+	les := New(&fake.DatabaseProvider{}, Config{Size: 1, Journal: path})
+	crash := runUntilCrash(les)
+	waitStarted(t, les)
+	crash()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := les.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Got %v, want the journal deleted", err)
+	}
+}
@@ -0,0 +1,191 @@
+package lessor
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// ErrNoSuchLease means there is no active lease with the given ID.
+var ErrNoSuchLease = errors.New("no such lease")
+
+// LeaseInfo describes an active lease.
+type LeaseInfo struct {
+	// Uniquely identifies the lease.
+	ID string
+
+	// The database on which the lease is held, or the first one if there are several.
+	Database string
+
+	// All the databases on which the lease is held.
+	Databases []string
+
+	// When the lease was granted, and how long the client waited for it.
+	GrantTime time.Time
+	Wait      time.Duration
+
+	// Information about the client's connection, for example its address.
+	Metadata map[string]string
+
+	// What the client told us about itself, if anything.
+	Client *pb.ClientInfo
+}
+
+// A lease granted to a client, which is what the opaque Lease handle refers to.
+type grant struct {
+	info      LeaseInfo            // const
+	connInfos []*pb.ConnectionInfo // const; how to connect to each database with the lease's credentials
+	revoked   chan struct{}        // closed when the lease is revoked
+
+	mu          sync.Mutex      // serializes checkpoint operations with each other and with Return()
+	checkpoints map[string]bool // guarded by mu; the names of the checkpoints taken so far
+}
+
+// Returns a new random lease ID.
+func newLeaseID() string {
+	return randomHex(8)
+}
+
+// Returns a random hex string of n bytes.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// Records a new lease on the databases.
+func (l *Lessor) newGrant(names []string, infos []*pb.ConnectionInfo, requested time.Time, opts LeaseOptions) *grant {
+	now := l.clock.Now()
+	g := &grant{
+		info: LeaseInfo{
+			ID:        newLeaseID(),
+			Database:  names[0],
+			Databases: names,
+			GrantTime: now,
+			Wait:      now.Sub(requested),
+			Metadata:  opts.Metadata,
+			Client:    opts.Client,
+		},
+		connInfos:   infos,
+		revoked:     make(chan struct{}),
+		checkpoints: make(map[string]bool),
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leases[g.info.ID] = g
+	return g
+}
+
+// Returns the grant to which the lease refers, and panics if the lease is not active.
+func (l *Lessor) getGrant(lease Lease) *grant {
+	g, ok := lease.(*grant)
+	if ok {
+		l.mu.Lock()
+		ok = l.leases[g.info.ID] == g
+		l.mu.Unlock()
+	}
+	if !ok {
+		panic(fmt.Sprintf("Invalid lease: %v", lease))
+	}
+	return g
+}
+
+// LeaseByID returns the active lease with the given ID, or ErrNoSuchLease.
+func (l *Lessor) LeaseByID(id string) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	g, ok := l.leases[id]
+	if !ok {
+		return nil, ErrNoSuchLease
+	}
+	return g, nil
+}
+
+// Database returns the name of the database on which the lease is held, or the
+// first one if there are several.
+func (l *Lessor) Database(lease Lease) string {
+	return l.getGrant(lease).info.Database
+}
+
+// Databases returns the names of all the databases on which the lease is held.
+func (l *Lessor) Databases(lease Lease) []string {
+	return l.getGrant(lease).info.Databases
+}
+
+// Info returns information about the lease.
+func (l *Lessor) Info(lease Lease) LeaseInfo {
+	return l.getGrant(lease).info
+}
+
+// Leases lists the active leases, ordered by grant time.
+func (l *Lessor) Leases() []LeaseInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ret []LeaseInfo
+	for _, g := range l.leases {
+		ret = append(ret, g.info)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].GrantTime.Before(ret[j].GrantTime) })
+	return ret
+}
+
+// Revoke forcibly ends the lease with the given ID. This only notifies the
+// holder through the Revoked() channel, and the holder is expected to stop
+// using the database and Return() the lease.
+func (l *Lessor) Revoke(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	g, ok := l.leases[id]
+	if !ok {
+		return ErrNoSuchLease
+	}
+	select {
+	case <-g.revoked:
+	default:
+		close(g.revoked)
+	}
+	return nil
+}
+
+// Revoked returns a channel that is closed when the lease is revoked.
+func (l *Lessor) Revoked(lease Lease) <-chan struct{} {
+	return l.getGrant(lease).revoked
+}
+
+// DescribeClient returns a short human-readable description of the client for logging.
+func DescribeClient(c *pb.ClientInfo) string {
+	if c == nil {
+		return "unknown client"
+	}
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	add("test", c.TestName)
+	add("package", c.Package)
+	add("host", c.Hostname)
+	if c.Pid != 0 {
+		add("pid", fmt.Sprint(c.Pid))
+	}
+	add("ci_job", c.CiJobId)
+	var labels []string
+	for k, v := range c.Labels {
+		labels = append(labels, fmt.Sprintf("%s:%s", k, v))
+	}
+	sort.Strings(labels)
+	add("labels", strings.Join(labels, ","))
+	if len(parts) == 0 {
+		return "unknown client"
+	}
+	return strings.Join(parts, " ")
+}
@@ -2,62 +2,366 @@ package lessor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"sync"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/karagog/clock-go"
+	"github.com/karagog/clock-go/real"
 
 	"github.com/karagog/db-provider/server/lessor/databaseprovider"
 	pb "github.com/karagog/db-provider/server/proto"
 )
 
+// DefaultPool is the name of the pool that serves requests which don't name one.
+const DefaultPool = "default"
+
+// DefaultPrefix is the prefix of the database names unless configured.
+const DefaultPrefix = "testserver"
+
 // Lease is an opaque handle for referencing your instance lease.
 type Lease interface{}
 
+// Config configures the pool of databases managed by a Lessor.
+type Config struct {
+	// Name identifies the pool to clients. Leave empty for the DefaultPool.
+	Name string
+
+	// Prefix starts the names of the pool's databases, so that several
+	// providers can share a server without touching each other's databases.
+	// Leave empty for the DefaultPrefix.
+	Prefix string
+
+	// InstanceID optionally follows the prefix in the database names, to tell
+	// apart providers that share the prefix.
+	InstanceID string
+
+	// RandomSuffix ends the name of every database with random characters, so
+	// names aren't reused when the pool grows again or the provider restarts.
+	RandomSuffix bool
+
+	// We will set up and manage this many databases, or at least this many if
+	// the pool may grow.
+	Size int
+
+	// MaxSize lets the pool grow beyond Size, up to this many databases, while
+	// clients are waiting. Zero means the pool doesn't grow.
+	MaxSize int
+
+	// IdleTimeout is how long a database beyond Size may sit idle before it's
+	// dropped to shrink the pool. Zero means 5 minutes.
+	IdleTimeout time.Duration
+
+	// Clock tells the time of grants. Leave nil to use the real clock.
+	Clock clock.Clock
+
+	// Retention is how long databases are kept after their lease ends if the
+	// lessee asked to retain them, e.g. for debugging a failed test.
+	// Zero means an hour.
+	Retention time.Duration
+
+	// ResetAttempts is how many times in a row a database may fail to reset
+	// before it's quarantined and replaced. Zero means 5.
+	ResetAttempts int
+
+	// ResetBackoff is how long to wait before retrying a failed reset the first
+	// time, which doubles with every failure. Zero means a second.
+	ResetBackoff time.Duration
+
+	// MinCapacity is how many usable databases the pool needs, below which the
+	// lessor raises the alarm. Zero means the whole pool.
+	MinCapacity int
+
+	// ResetStrategy is how databases are cleaned up after their lease ends.
+	// The default is to drop and recreate them.
+	ResetStrategy databaseprovider.ResetStrategy
+
+	// VerifyResets checks every database before it becomes ready, if the
+	// provider can, and quarantines those that aren't clean.
+	VerifyResets bool
+
+	// Journal is the path of a file in which to record the state of the
+	// pool's databases, so that after a restart the clean ones are reused and
+	// the others are reset. Empty means no journal, and all the databases left
+	// over from a previous run are dropped.
+	Journal string
+}
+
+// LeaseOptions customize a lease request.
+type LeaseOptions struct {
+	// Schema pre-loads the database with a schema, unless nil.
+	Schema *Schema
+
+	// Metadata about the client's connection, which is reported along with the lease.
+	Metadata map[string]string
+
+	// What the client told us about itself, which is reported along with the lease.
+	Client *pb.ClientInfo
+
+	// How many databases to lease at once. They are granted all together, or
+	// not at all. Zero means one.
+	Count int
+}
+
 type Lessor struct {
-	numDB   int // const
-	readyCh chan string
-	resetCh chan string
+	name          string                         // const
+	base          string                         // const; the prefix of the names of the provider's databases
+	randomSuffix  bool                           // const
+	numDB         int                            // const; the minimum size of the pool
+	maxSize       int                            // const
+	idleTimeout   time.Duration                  // const
+	retention     time.Duration                  // const
+	resetAttempts int                            // const
+	resetBackoff  time.Duration                  // const
+	minCapacity   int                            // const
+	owned         *regexp.Regexp                 // const; matches the names of the databases we create
+	journalPath   string                         // const
+	resetStrategy databaseprovider.ResetStrategy // const
+	verifyReset   bool                           // const
+	resetCh       chan string
+	retainCh      chan struct{} // wakes up the retention worker when a database is retained
+	idleCh        chan struct{} // wakes up the scale worker when a database becomes ready
+	endedCh       chan struct{} // wakes up WaitDrained() when a lease ends
+	drainCh       chan struct{} // closed when the lessor starts draining
+
+	provider databaseprovider.DatabaseProvider
+	clock    clock.Clock
 
-	provider  databaseprovider.DatabaseProvider
-	databases map[string]bool
+	mu          sync.Mutex           // guards the members below
+	databases   map[string]*database // by name
+	templates   map[string]*template // by schema hash
+	leases      map[string]*grant    // by lease ID
+	ready       []string             // the databases available to lease, oldest first
+	queue       []*waiter            // the clients waiting for a lease, in order of arrival
+	avgHold     time.Duration        // how long clients typically hold their leases
+	initialized int                  // the number of databases that have been reset at least once
+	retained    map[string]Retained  // by the name of the retained database
+	quarantined map[string]Quarantined
+	nextIndex   int          // the number in the name of the next database to add to the pool
+	lowCapacity bool         // whether the alarm about low capacity is raised
+	scaleEvents []ScaleEvent // the latest decisions to grow or shrink the pool, oldest first
+	draining    bool         // whether the lessor has stopped granting leases
+	reclaimed   []string     // the leftover databases dropped at startup
+	recovered   int          // how many databases were recovered from the journal at startup
+	journal     *journal     // records the state of the databases, or nil
+
+	// How many databases in a row failed verification.
+	verifyFailures int
+}
+
+// The state of a database in the pool.
+type database struct {
+	state dbState // guarded by the lessor's mutex
+
+	// Whether the database has been reset at least once.
+	initialized bool
+
+	// The template from which the database was cloned, or empty if it was
+	// created empty. Only the lessee or the reset worker may access this,
+	// except while the database is ready, when it is guarded by the lessor's mutex.
+	template string
+
+	// Whether the lessee may have taken checkpoints, which must be dropped
+	// when the database is reset. Only the lessee or the reset worker may
+	// access this.
+	checkpoints bool
+
+	// Whether recreating the database failed partway, or its tables were
+	// moved away to retain them, so that it must be recreated from scratch.
+	// Only the lessee or the reset worker may access this.
+	broken bool
+
+	// How many times in a row the database failed to reset. Guarded by the
+	// lessor's mutex.
+	failures int
+
+	// When the database last became ready. Guarded by the lessor's mutex.
+	readySince time.Time
 }
 
-// We will set up and manage this many databases.
-func New(p databaseprovider.DatabaseProvider, numDB int) *Lessor {
-	return &Lessor{
-		provider:  p,
-		numDB:     numDB,
-		readyCh:   make(chan string, numDB),
-		resetCh:   make(chan string, numDB),
-		databases: make(map[string]bool),
+// New creates a lessor that manages a pool of databases from the given provider.
+func New(p databaseprovider.DatabaseProvider, cfg Config) *Lessor {
+	name := cfg.Name
+	if name == "" {
+		name = DefaultPool
+	}
+	c := cfg.Clock
+	if c == nil {
+		c = &real.Clock{}
+	}
+	retention := cfg.Retention
+	if retention == 0 {
+		retention = defaultRetention
+	}
+	attempts := cfg.ResetAttempts
+	if attempts == 0 {
+		attempts = defaultResetAttempts
 	}
+	backoff := cfg.ResetBackoff
+	if backoff == 0 {
+		backoff = defaultResetBackoff
+	}
+	minCapacity := cfg.MinCapacity
+	if minCapacity == 0 {
+		minCapacity = cfg.Size
+	}
+	maxSize := cfg.MaxSize
+	if maxSize < cfg.Size {
+		maxSize = cfg.Size
+	}
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout == 0 {
+		idleTimeout = defaultIdleTimeout
+	}
+	l := &Lessor{
+		provider:      p,
+		clock:         c,
+		name:          name,
+		base:          NamePrefix(cfg.Prefix, cfg.InstanceID),
+		randomSuffix:  cfg.RandomSuffix,
+		numDB:         cfg.Size,
+		maxSize:       maxSize,
+		idleTimeout:   idleTimeout,
+		retention:     retention,
+		resetAttempts: attempts,
+		resetBackoff:  backoff,
+		minCapacity:   minCapacity,
+		journalPath:   cfg.Journal,
+		resetStrategy: cfg.ResetStrategy,
+		verifyReset:   cfg.VerifyResets,
+		resetCh:       make(chan string, maxSize),
+		retainCh:      make(chan struct{}, 1),
+		idleCh:        make(chan struct{}, 1),
+		endedCh:       make(chan struct{}, 1),
+		drainCh:       make(chan struct{}),
+		databases:     make(map[string]*database),
+		templates:     make(map[string]*template),
+		leases:        make(map[string]*grant),
+		retained:      make(map[string]Retained),
+		quarantined:   make(map[string]Quarantined),
+	}
+	l.owned = ownedRE(l.Prefix())
+	return l
 }
 
+// Name returns the name of the pool.
+func (l *Lessor) Name() string { return l.name }
+
+// Size returns the minimum number of databases in the pool.
+func (l *Lessor) Size() int { return l.numDB }
+
+// MaxSize returns the number of databases up to which the pool may grow.
+func (l *Lessor) MaxSize() int { return l.maxSize }
+
 func (l *Lessor) Run(ctx context.Context) {
 	var wg sync.WaitGroup
-	wg.Add(l.numDB)
-	for i := 0; i < l.numDB; i++ {
-		// Spawn a pool of workers to reset databases in parallel.
+	wg.Add(l.maxSize + 2)
+	go func() {
+		defer wg.Done()
+		l.retentionWorker(ctx)
+	}()
+	if a, ok := l.provider.(databaseprovider.Availability); ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.availabilityWorker(ctx, a)
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		l.scaleWorker(ctx)
+	}()
+	for i := 0; i < l.maxSize; i++ {
+		// Spawn a pool of workers to reset databases in parallel, enough for
+		// the pool at its largest.
 		go func() {
 			defer wg.Done()
 			l.resetWorker(ctx)
 		}()
+	}
+	// Clean up after a previous run before creating anything, so we don't drop
+	// our own databases.
+	names := l.reclaim(ctx, l.openJournal())
 
-		// Create a new instance handle and pass it to the reset worker.
-		name := fmt.Sprintf("testserver_db_%d", i)
-		l.databases[name] = true
-		l.resetCh <- name
+	// Create the instance handles all at once, so the pool doesn't grow until
+	// it has its minimum size, and pass them to the reset workers.
+	l.mu.Lock()
+	for len(l.databases) < l.numDB {
+		names = append(names, l.addDatabaseLocked())
 	}
+	l.compactJournalLocked()
+	l.mu.Unlock()
+	l.resetAll(names)
 	wg.Wait()
 }
 
+// Adds a database with a new name to the pool, which must then be reset.
+func (l *Lessor) addDatabaseLocked() string {
+	name := l.Prefix() + fmt.Sprintf("db_%d", l.nextIndex)
+	if l.randomSuffix {
+		name += "_" + randomHex(3)
+	}
+	l.nextIndex++
+	l.databases[name] = &database{}
+	l.journalLocked(name)
+	return name
+}
+
+// NamePrefix returns the prefix of the names of all the databases of a
+// provider, which consists of the prefix and the instance ID, if any.
+func NamePrefix(prefix, instanceID string) string {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	if instanceID == "" {
+		return prefix + "_"
+	}
+	return fmt.Sprintf("%s_%s_", prefix, instanceID)
+}
+
+// Prefix returns the prefix of the names of all the databases that belong to
+// the pool. Databases in the default pool keep their original names, while the
+// others are qualified by the pool name so several pools can share a server.
+// Pools of different providers may end up with the same prefix, e.g. a named
+// pool and another provider's instance ID, so the caller must make sure that
+// only one pool on a server uses it.
+func (l *Lessor) Prefix() string {
+	if l.name == DefaultPool {
+		return l.base
+	}
+	return fmt.Sprintf("%s%s_", l.base, l.name)
+}
+
 func (l *Lessor) resetWorker(ctx context.Context) {
 	for {
 		select {
 		case name := <-l.resetCh:
-			if err := l.reset(ctx, name); err != nil {
-				glog.Errorf("Dropping database %s due to error: %s", name, err)
+			err := l.reset(ctx, name)
+			l.mu.Lock()
+			db := l.databases[name]
+			if !db.initialized {
+				db.initialized = true
+				l.initialized++
+			}
+			if err == nil && db.failures > 0 {
+				glog.Infof("Database %s reset after %d failures", name, db.failures)
+				db.failures = 0
+				l.checkCapacityLocked()
+			}
+			if err == nil {
+				l.verifyFailures = 0
+			}
+			l.mu.Unlock()
+			var verr *verifyError
+			switch {
+			case err == nil || ctx.Err() != nil:
+			case errors.As(err, &verr):
+				l.verifyFailed(ctx, name, err)
+			default:
+				l.resetFailed(ctx, name, err)
 			}
 		case <-ctx.Done():
 			return
@@ -67,36 +371,126 @@ func (l *Lessor) resetWorker(ctx context.Context) {
 
 // Blocks until a lease is granted, or the context has ended.
 func (l *Lessor) Lease(ctx context.Context) (Lease, error) {
-	glog.V(2).Infof("Lease called")
-	select {
-	case l := <-l.readyCh:
-		glog.V(2).Infof("Handing out lease on %q", l)
-		return l, nil
-	case <-ctx.Done():
-		return "", ctx.Err()
-	}
+	return l.LeaseWithOptions(ctx, LeaseOptions{})
+}
+
+// Like Lease(), but customizes the database according to the options.
+func (l *Lessor) LeaseWithOptions(ctx context.Context, opts LeaseOptions) (Lease, error) {
+	return l.Request(opts).Wait(ctx)
 }
 
+// ConnectionInfo returns how to connect to the leased database, or the first
+// one if there are several, with the lease's own credentials.
 func (l *Lessor) ConnectionInfo(lease Lease) *pb.ConnectionInfo {
-	return l.provider.GetConnectionInfo(lease.(string))
+	return l.getGrant(lease).connInfos[0]
+}
+
+// ConnectionInfos returns how to connect to each of the leased databases.
+func (l *Lessor) ConnectionInfos(lease Lease) []*pb.ConnectionInfo {
+	return l.getGrant(lease).connInfos
 }
 
 func (l *Lessor) Return(lease Lease) {
-	glog.V(2).Infof("Return called on lease: '%s'", lease)
-	name := lease.(string)
-	if _, ok := l.databases[name]; !ok {
-		panic(fmt.Sprintf("Invalid lease: %v", lease))
+	g := l.getGrant(lease)
+	g.mu.Lock() // wait for any checkpoint operation to finish
+	defer g.mu.Unlock()
+	glog.V(2).Infof("Return called on lease %s of %q", g.info.ID, g.info.Databases)
+	l.end(g)
+	l.resetAll(g.info.Databases)
+}
+
+// Ends the lease, after which its databases are waiting to be reset.
+// The caller must hold the grant's mutex.
+func (l *Lessor) end(g *grant) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.leases, g.info.ID)
+	l.recordHoldLocked(l.clock.Now().Sub(g.info.GrantTime))
+	for _, name := range g.info.Databases {
+		l.databases[name].state = resetting
 	}
-	l.resetCh <- name
+	select {
+	case l.endedCh <- struct{}{}:
+	default:
+	}
+}
+
+// Hands the databases to the reset workers.
+func (l *Lessor) resetAll(names []string) {
+	for _, name := range names {
+		l.resetCh <- name
+	}
+}
+
+func (l *Lessor) getDatabase(name string) *database {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.databases[name]
 }
 
-func (l *Lessor) reset(ctx context.Context, database string) error {
-	if err := l.provider.DropDatabase(ctx, database); err != nil {
+// Resets the database to the same template it had before, so that subsequent
+// leases of the same schema don't have to clone it again.
+func (l *Lessor) reset(ctx context.Context, name string) error {
+	db := l.getDatabase(name)
+	// Lock out the previous lessee, if any.
+	if err := l.provider.DropUsers(ctx, name); err != nil {
+		return err
+	}
+	if db.checkpoints {
+		if err := l.provider.DropCheckpoints(ctx, name); err != nil {
+			return err
+		}
+		db.checkpoints = false
+	}
+	if err := l.restore(ctx, name, db); err != nil {
 		return err
 	}
-	if err := l.provider.CreateDatabase(ctx, database); err != nil {
+	if err := l.verify(ctx, name, db); err != nil {
 		return err
 	}
-	l.readyCh <- database
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked(name)
+	return nil
+}
+
+// Restores the database to how it was created with the pool's reset strategy.
+// A database that may not be intact, because it was never created, failed to
+// reset, failed to be recreated for a lease or was retained, is recreated from
+// scratch instead.
+func (l *Lessor) restore(ctx context.Context, name string, db *database) error {
+	l.mu.Lock()
+	intact := db.initialized && db.failures == 0 && !db.broken
+	l.mu.Unlock()
+	if !intact {
+		return l.recreate(ctx, name, db.template, true)
+	}
+	return l.provider.ResetDatabase(ctx, name, db.template, l.resetStrategy)
+}
+
+// Drops and recreates the database from the template, or empty if the
+// template is empty. Unless forced, it does nothing if the database was
+// already created from the template.
+func (l *Lessor) recreate(ctx context.Context, name, tmpl string, force bool) error {
+	db := l.getDatabase(name)
+	if !force && db.template == tmpl {
+		return nil
+	}
+	db.broken = true
+	if err := l.provider.DropDatabase(ctx, name); err != nil {
+		return err
+	}
+	db.template = ""
+	if tmpl == "" {
+		if err := l.provider.CreateDatabase(ctx, name); err != nil {
+			return err
+		}
+	} else {
+		if err := l.provider.CloneDatabase(ctx, tmpl, name); err != nil {
+			return err
+		}
+		db.template = tmpl
+	}
+	db.broken = false
 	return nil
 }
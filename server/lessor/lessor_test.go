@@ -2,18 +2,23 @@ package lessor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/karagog/clock-go/simulated"
 	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+	pb "github.com/karagog/db-provider/server/proto"
 
 	"github.com/go-test/deep"
 )
 
 func TestLessor(t *testing.T) {
 	p := &fake.DatabaseProvider{}
-	les := New(p, 1)
+	les := New(p, Config{Size: 1})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	done := make(chan bool)
@@ -84,7 +89,7 @@ func TestLessor(t *testing.T) {
 
 func TestDropDatabaseError(t *testing.T) {
 	p := &fake.DatabaseProvider{DropErr: fmt.Errorf("Oof!")}
-	les := New(p, 1)
+	les := New(p, Config{Size: 1})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go les.Run(ctx)
@@ -98,7 +103,7 @@ func TestDropDatabaseError(t *testing.T) {
 
 func TestCreateDatabaseError(t *testing.T) {
 	p := &fake.DatabaseProvider{CreateErr: fmt.Errorf("Oof!")}
-	les := New(p, 1)
+	les := New(p, Config{Size: 1})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go les.Run(ctx)
@@ -112,7 +117,7 @@ func TestCreateDatabaseError(t *testing.T) {
 
 func TestReturnInvalidLease(t *testing.T) {
 	p := &fake.DatabaseProvider{}
-	les := New(p, 1)
+	les := New(p, Config{Size: 1})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go les.Run(ctx)
@@ -123,3 +128,220 @@ func TestReturnInvalidLease(t *testing.T) {
 	}()
 	les.Return(Lease("invalid")) // this lease did not come from a call to Lease()
 }
+
+func TestPoolDatabaseNames(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		cfg     Config
+		expName string // a regexp
+	}{
+		{"default", Config{}, "^testserver_db_0$"},
+		{"named default", Config{Name: DefaultPool}, "^testserver_db_0$"},
+		{"named", Config{Name: "legacy"}, "^testserver_legacy_db_0$"},
+		{"prefix", Config{Prefix: "team"}, "^team_db_0$"},
+		{"instance", Config{Name: "legacy", Prefix: "team", InstanceID: "ci"}, "^team_ci_legacy_db_0$"},
+		{"random suffix", Config{InstanceID: "ci", RandomSuffix: true}, "^testserver_ci_db_0_[0-9a-f]{6}$"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := &fake.DatabaseProvider{}
+			tc.cfg.Size = 1
+			les := New(p, tc.cfg)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go les.Run(ctx)
+
+			l, err := les.Lease(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := les.Database(l), tc.expName; !regexp.MustCompile(want).MatchString(got) {
+				t.Errorf("Got database %q, want %q", got, want)
+			}
+			if !strings.HasPrefix(les.Database(l), les.Prefix()) {
+				t.Errorf("Got database %q, want prefix %q", les.Database(l), les.Prefix())
+			}
+			if !les.owned.MatchString(les.Database(l)) {
+				t.Errorf("The lessor doesn't recognize database %q as its own", les.Database(l))
+			}
+			if les.Name() == "" {
+				t.Error("Got empty pool name, want name")
+			}
+		})
+	}
+}
+
+// Pools whose databases would have the same names have the same prefix, so
+// locking the prefix keeps them apart.
+func TestPoolPrefixCollision(t *testing.T) {
+	named := New(&fake.DatabaseProvider{}, Config{Size: 1, Name: "ci"})
+	instance := New(&fake.DatabaseProvider{}, Config{Size: 1, InstanceID: "ci"})
+	if named.Prefix() != instance.Prefix() {
+		t.Fatalf("Got prefixes %q and %q, want the same", named.Prefix(), instance.Prefix())
+	}
+	if def := New(&fake.DatabaseProvider{}, Config{Size: 1}); def.owned.MatchString(instance.Prefix() + "db_0") {
+		t.Fatalf("The default pool owns the databases of pool %q", instance.Prefix())
+	}
+}
+
+func TestLeaseUsers(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	// Each lease gets its own users, which are dropped when the database is reset.
+	for i := 0; i < 2; i++ {
+		l, err := les.Lease(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := les.ConnectionInfo(l), &p.Info; got != want {
+			t.Fatalf("Got connection info %v, want the lease's", got)
+		}
+		les.Return(l)
+	}
+	if _, err := les.Lease(ctx); err != nil {
+		t.Fatal(err)
+	}
+	db := []string{"testserver_db_0"}
+	if diff := deep.Equal(p.CreateUsersList, append(db, db[0], db[0])); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(p.DropUsersList, append(db, db[0], db[0])); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestCreateUsersError(t *testing.T) {
+	p := &fake.DatabaseProvider{CreateUsersErr: errors.New("Oof!")}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	if _, err := les.Lease(ctx); err != p.CreateUsersErr {
+		t.Fatalf("Got error %v, want %v", err, p.CreateUsersErr)
+	}
+	// The database goes back to be reset, rather than being lost.
+	for les.Stats().Ready == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStats(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 1})
+	if got, want := les.Stats(), (Stats{Starting: true}); got != want {
+		t.Fatalf("Got %+v, want %+v", got, want)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := les.Stats(), (Stats{Total: 1, Leased: 1}); got != want {
+		t.Fatalf("Got %+v, want %+v", got, want)
+	}
+
+	// Wait for a lease in the background.
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		les.Lease(waitCtx)
+	}()
+	for les.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancelWait()
+	<-done
+	if got, want := les.Stats(), (Stats{Total: 1, Leased: 1}); got != want {
+		t.Fatalf("Got %+v, want %+v", got, want)
+	}
+
+	// After returning, the database is reset and becomes ready again.
+	les.Return(l)
+	for les.Stats().Ready == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := les.Stats(), (Stats{Total: 1, Ready: 1}); got != want {
+		t.Fatalf("Got %+v, want %+v", got, want)
+	}
+}
+
+func TestLeasesAndRevoke(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.LeaseWithOptions(ctx, LeaseOptions{Metadata: map[string]string{"peer": "me"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := les.Info(l)
+	if diff := deep.Equal(les.Leases(), []LeaseInfo{{
+		ID:        info.ID,
+		Database:  "testserver_db_0",
+		Databases: []string{"testserver_db_0"},
+		GrantTime: c.Now(),
+		Metadata:  map[string]string{"peer": "me"},
+	}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	if err := les.Revoke("invalid"); err != ErrNoSuchLease {
+		t.Fatalf("Got error %v, want %v", err, ErrNoSuchLease)
+	}
+	select {
+	case <-les.Revoked(l):
+		t.Fatal("Lease revoked early")
+	default:
+	}
+	if err := les.Revoke(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-les.Revoked(l):
+	default:
+		t.Fatal("Lease not revoked")
+	}
+
+	// The holder returns the lease, after which it's no longer listed.
+	les.Return(l)
+	if got := les.Leases(); len(got) != 0 {
+		t.Fatalf("Got leases %v, want none", got)
+	}
+	if err := les.Revoke(info.ID); err != ErrNoSuchLease {
+		t.Fatalf("Got error %v, want %v", err, ErrNoSuchLease)
+	}
+}
+
+func TestDescribeClient(t *testing.T) {
+	for _, tc := range []struct {
+		client *pb.ClientInfo
+		want   string
+	}{
+		{nil, "unknown client"},
+		{&pb.ClientInfo{}, "unknown client"},
+		{
+			&pb.ClientInfo{
+				TestName: "TestFoo",
+				Hostname: "ci-worker",
+				Pid:      123,
+				Labels:   map[string]string{"team": "storage", "shard": "2"},
+			},
+			"test=TestFoo host=ci-worker pid=123 labels=shard:2,team:storage",
+		},
+	} {
+		if got := DescribeClient(tc.client); got != tc.want {
+			t.Errorf("Got %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// How much weight a newly returned lease carries in the average hold time.
+const holdTimeWeight = 0.2
+
+// ErrBadCount means the client asked for more databases than the pool has, or a negative number.
+var ErrBadCount = errors.New("invalid number of databases")
+
+// A client waiting in line for databases.
+type waiter struct {
+	tmpl  string        // the template the client wants, if any
+	count int           // how many databases the client wants
+	ch    chan []string // receives the names of the granted databases
+}
+
+// Request is a request for a lease, which waits its turn in the pool's queue.
+// Requests are served in the order they started waiting.
+type Request struct {
+	l    *Lessor      // const
+	opts LeaseOptions // const
+	w    *waiter      // guarded by the lessor's mutex; nil until the request is queued
+}
+
+// Request creates a lease request, which joins the queue when you call Wait().
+func (l *Lessor) Request(opts LeaseOptions) *Request {
+	return &Request{l: l, opts: opts}
+}
+
+// Wait blocks until the lease is granted, or the context has ended. In the
+// latter case the request leaves the queue.
+func (r *Request) Wait(ctx context.Context) (Lease, error) {
+	glog.V(2).Infof("Lease called")
+	l := r.l
+	requested := l.clock.Now()
+	var tmpl string
+	if r.opts.Schema != nil {
+		var err error
+		if tmpl, err = l.template(ctx, r.opts.Schema); err != nil {
+			return nil, err
+		}
+	}
+
+	count := r.opts.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > l.maxSize {
+		return nil, fmt.Errorf("%w: asked for %d databases from a pool of %d", ErrBadCount, count, l.maxSize)
+	}
+	names, err := r.dequeue(ctx, tmpl, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we fail to prepare the databases, they go back to be reset.
+	fail := func(name string, err error) (Lease, error) {
+		glog.Errorf("Error preparing database %s: %s", name, err)
+		for _, name := range names {
+			l.setState(name, resetting)
+			l.resetCh <- name
+		}
+		return nil, err
+	}
+
+	// The databases may have been cloned from a different template than the
+	// one we want, in which case we need to recreate them.
+	for _, name := range names {
+		if err := l.recreate(ctx, name, tmpl, false); err != nil {
+			return fail(name, err)
+		}
+	}
+
+	// Each lease gets its own credentials, which only have access to its own
+	// databases, so a buggy test can't interfere with another.
+	var infos []*pb.ConnectionInfo
+	for _, name := range names {
+		info, err := l.provider.CreateUsers(ctx, name)
+		if err != nil {
+			return fail(name, err)
+		}
+		infos = append(infos, info)
+	}
+	g := l.newGrant(names, infos, requested, r.opts)
+	glog.Infof("Granted lease %s on %q to %s", g.info.ID, names, DescribeClient(r.opts.Client))
+	return g, nil
+}
+
+// Position returns the request's place in the queue, starting at 1 for the
+// next in line. It returns 0 if the request is not waiting in the queue.
+func (r *Request) Position() int {
+	r.l.mu.Lock()
+	defer r.l.mu.Unlock()
+	return r.positionLocked()
+}
+
+func (r *Request) positionLocked() int {
+	for i, w := range r.l.queue {
+		if w == r.w {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// EstimatedWait guesses how long it will be until the request is granted,
+// based on how long clients have been holding their leases. It returns zero if
+// the request is not waiting or there isn't enough history to tell.
+func (r *Request) EstimatedWait() time.Duration {
+	l := r.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pos := r.positionLocked()
+	if pos == 0 {
+		return 0
+	}
+	demand := 0 // how many databases must be granted, up to and including ours
+	for _, w := range l.queue[:pos] {
+		demand += w.count
+	}
+	capacity := l.capacityLocked()
+	if capacity == 0 || l.avgHold == 0 {
+		return 0
+	}
+
+	// Every database serves one client in each round of leases.
+	rounds := (demand + capacity - 1) / capacity
+	return time.Duration(rounds) * l.avgHold
+}
+
+// Takes ready databases immediately if nobody is ahead of us, otherwise
+// waits in line for them. We get all of them at once or none, so that clients
+// who need several databases can't deadlock by each holding some of them.
+func (r *Request) dequeue(ctx context.Context, tmpl string, count int) ([]string, error) {
+	l := r.l
+	w := &waiter{tmpl: tmpl, count: count, ch: make(chan []string, 1)}
+	l.mu.Lock()
+	if l.draining {
+		l.mu.Unlock()
+		return nil, ErrDraining
+	}
+	if len(l.queue) == 0 && len(l.ready) >= count {
+		names := l.takeReadyLocked(tmpl, count)
+		l.mu.Unlock()
+		return names, nil
+	}
+	l.queue = append(l.queue, w)
+	r.w = w
+	grown := l.growLocked()
+	l.mu.Unlock()
+	l.resetAll(grown)
+
+	var err error
+	select {
+	case names := <-w.ch:
+		return names, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-l.drainCh:
+		err = ErrDraining
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.removeWaiterLocked(w) {
+		// Those behind us may be able to go ahead now.
+		l.serveQueueLocked()
+	} else {
+		// We were granted the databases just as we gave up, so pass them on.
+		for _, name := range <-w.ch {
+			l.releaseLocked(name)
+		}
+	}
+	return nil, err
+}
+
+// Removes ready databases from the list, preferring those that were already
+// cloned from the template so they don't have to be recreated.
+func (l *Lessor) takeReadyLocked(tmpl string, count int) []string {
+	var names, rest []string
+	for _, name := range l.ready {
+		if len(names) < count && l.databases[name].template == tmpl {
+			names = append(names, name)
+		} else {
+			rest = append(rest, name)
+		}
+	}
+	for len(names) < count {
+		names = append(names, rest[0])
+		rest = rest[1:]
+	}
+	l.ready = rest
+	for _, name := range names {
+		l.databases[name].state = leased
+		l.journalLocked(name)
+	}
+	return names
+}
+
+// Removes the waiter from the queue, and returns false if it wasn't there.
+func (l *Lessor) removeWaiterLocked(w *waiter) bool {
+	for i, q := range l.queue {
+		if q == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Makes the database available, handing it to the clients in line if there are any.
+func (l *Lessor) releaseLocked(name string) {
+	db := l.databases[name]
+	db.state = ready
+	db.readySince = l.clock.Now()
+	l.ready = append(l.ready, name)
+	l.journalLocked(name)
+	l.serveQueueLocked()
+	if len(l.ready) > 0 && len(l.databases) > l.numDB {
+		// Let the scale worker time out the idle databases.
+		select {
+		case l.idleCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Grants ready databases to the clients in line, in order. A client who wants
+// more databases than are ready holds up those behind them, so they don't starve.
+func (l *Lessor) serveQueueLocked() {
+	if l.draining {
+		return // the waiters are leaving
+	}
+	for len(l.queue) > 0 && len(l.ready) >= l.queue[0].count {
+		w := l.queue[0]
+		l.queue = l.queue[1:]
+		w.ch <- l.takeReadyLocked(w.tmpl, w.count)
+	}
+}
+
+// Updates the average time clients hold their leases, which is how we
+// estimate the wait.
+func (l *Lessor) recordHoldLocked(held time.Duration) {
+	if l.avgHold == 0 {
+		l.avgHold = held
+		return
+	}
+	l.avgHold = time.Duration((1-holdTimeWeight)*float64(l.avgHold) + holdTimeWeight*float64(held))
+}
@@ -0,0 +1,146 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/karagog/clock-go/simulated"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+// The result of waiting for a lease.
+type waitResult struct {
+	lease Lease
+	err   error
+}
+
+// Starts waiting for the request in the background, and returns only once it's in the queue.
+func startWaiting(ctx context.Context, r *Request) <-chan waitResult {
+	ch := make(chan waitResult, 1)
+	go func() {
+		l, err := r.Wait(ctx)
+		ch <- waitResult{l, err}
+	}()
+	for r.Position() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return ch
+}
+
+func TestQueueOrder(t *testing.T) {
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(&fake.DatabaseProvider{}, Config{Size: 1, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	held, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Three clients line up for the only database, and the second one gives up.
+	var reqs []*Request
+	var results []<-chan waitResult
+	cancelSecond := func() {}
+	for i := 0; i < 3; i++ {
+		reqCtx := ctx
+		if i == 1 {
+			reqCtx, cancelSecond = context.WithCancel(ctx)
+		}
+		r := les.Request(LeaseOptions{})
+		reqs = append(reqs, r)
+		results = append(results, startWaiting(reqCtx, r))
+		if got, want := r.Position(), i+1; got != want {
+			t.Fatalf("Request %d got position %d, want %d", i, got, want)
+		}
+	}
+	if got := reqs[0].EstimatedWait(); got != 0 {
+		t.Errorf("Got estimated wait %v before any lease was returned, want 0", got)
+	}
+	cancelSecond()
+	if res := <-results[1]; res.err != context.Canceled {
+		t.Fatalf("Got error %v, want %v", res.err, context.Canceled)
+	}
+	if got, want := reqs[2].Position(), 2; got != want {
+		t.Fatalf("Got position %d after cancellation, want %d", got, want)
+	}
+	if got, want := les.Stats().Waiting, 2; got != want {
+		t.Fatalf("Got %d waiting, want %d", got, want)
+	}
+
+	// The database goes to the first in line.
+	c.Advance(10 * time.Minute)
+	les.Return(held)
+	res := <-results[0]
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if got, want := reqs[2].Position(), 1; got != want {
+		t.Fatalf("Got position %d, want %d", got, want)
+	}
+
+	// The last one is next, and should expect to wait as long as leases are typically held.
+	if got, want := reqs[2].EstimatedWait(), 10*time.Minute; got != want {
+		t.Errorf("Got estimated wait %v, want %v", got, want)
+	}
+	les.Return(res.lease)
+	if res := <-results[2]; res.err != nil {
+		t.Fatal(res.err)
+	}
+	if got := reqs[2].Position(); got != 0 {
+		t.Errorf("Got position %d after the grant, want 0", got)
+	}
+}
+
+func TestQueueMultipleDatabases(t *testing.T) {
+	les := New(&fake.DatabaseProvider{}, Config{Size: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	if _, err := les.LeaseWithOptions(ctx, LeaseOptions{Count: 4}); !errors.Is(err, ErrBadCount) {
+		t.Fatalf("Got error %v, want %v", err, ErrBadCount)
+	}
+	held, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A client who wants the whole pool has to wait, and so does everyone
+	// behind them even though there are databases to spare.
+	all := startWaiting(ctx, les.Request(LeaseOptions{Count: 3}))
+	one := startWaiting(ctx, les.Request(LeaseOptions{}))
+	for les.Stats().Ready != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case res := <-one:
+		t.Fatalf("Got lease %v out of turn", res)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Once the database comes back, they're all granted together.
+	les.Return(held)
+	res := <-all
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	names := append([]string(nil), les.Databases(res.lease)...)
+	sort.Strings(names)
+	if diff := deep.Equal(names, []string{"testserver_db_0", "testserver_db_1", "testserver_db_2"}); diff != nil {
+		t.Fatal(diff)
+	}
+	if got, want := len(les.ConnectionInfos(res.lease)), 3; got != want {
+		t.Fatalf("Got %d connection infos, want %d", got, want)
+	}
+
+	les.Return(res.lease)
+	if res := <-one; res.err != nil {
+		t.Fatal(res.err)
+	}
+}
@@ -0,0 +1,104 @@
+package lessor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+)
+
+// Returns a regexp that matches the names of the databases that the lessor of
+// the pool creates: the pool's databases, the retained copies and checkpoints
+// of those, and the templates. The databases of named pools share the prefix
+// of the default pool, but their names don't match its regexp.
+func ownedRE(prefix string) *regexp.Regexp {
+	return regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `(db_[0-9]+(_.*)?|tmpl_[0-9a-f]+)$`)
+}
+
+// Drops the databases of the pool left over from a previous run, e.g. if the
+// provider crashed, along with their users, so the pool starts clean. The
+// databases in the journal, if any, are recovered into the pool instead: the
+// clean ones are ready right away, and the others are returned to be reset.
+// Errors are logged, since the pool works anyway. A provider that can't list
+// all its databases yet, e.g. because a server is down, drops the pool's
+// leftovers that it finds later itself.
+func (l *Lessor) reclaim(ctx context.Context, journaled map[string]journalEntry) (dirty []string) {
+	if r, ok := l.provider.(databaseprovider.Reclaimer); ok {
+		r.ReclaimLater(l.Prefix(), l.owned.MatchString)
+	}
+	names, err := l.provider.ListDatabases(ctx, l.Prefix())
+	if err != nil {
+		glog.Errorf("Error listing the leftover databases of pool %q: %s", l.name, err)
+		return nil
+	}
+	var dropped, recovered []string
+	for _, name := range names {
+		if !l.owned.MatchString(name) {
+			continue
+		}
+		if e, ok := journaled[name]; ok && l.recover(e) {
+			recovered = append(recovered, name)
+			if e.State != journalReady || e.Template != "" {
+				dirty = append(dirty, name)
+			}
+			continue
+		}
+		if err := l.provider.DropUsers(ctx, name); err != nil {
+			glog.Errorf("Error dropping the users of leftover database %s: %s", name, err)
+			continue
+		}
+		if err := l.provider.DropDatabase(ctx, name); err != nil {
+			glog.Errorf("Error dropping leftover database %s: %s", name, err)
+			continue
+		}
+		dropped = append(dropped, name)
+	}
+	if len(dropped) > 0 {
+		glog.Warningf("Dropped %d databases of pool %q left over from a previous run: %q", len(dropped), l.name, dropped)
+	}
+	if len(recovered) > 0 {
+		glog.Infof("Recovered %d databases of pool %q from the journal, of which %d must be reset", len(recovered), l.name, len(dirty))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reclaimed = dropped
+	l.recovered = len(recovered)
+	return dirty
+}
+
+// Adds the database from the journal to the pool, unless the pool is full. A
+// database that was clean is ready right away, but one that was cloned from a
+// template is reset, since the templates aren't recovered.
+func (l *Lessor) recover(e journalEntry) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.databases) >= l.maxSize {
+		return false
+	}
+	var index int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(e.Database, l.Prefix()), "db_%d", &index); err == nil && index >= l.nextIndex {
+		l.nextIndex = index + 1
+	}
+	db := &database{state: resetting}
+	l.databases[e.Database] = db
+	if e.State == journalReady && e.Template == "" {
+		db.initialized = true
+		l.initialized++
+		l.releaseLocked(e.Database)
+	} else {
+		l.journalLocked(e.Database)
+	}
+	return true
+}
+
+// Reclaimed returns the names of the databases left over from a previous run,
+// which were dropped when the lessor started.
+func (l *Lessor) Reclaimed() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reclaimed
+}
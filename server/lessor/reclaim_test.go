@@ -0,0 +1,112 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestReclaim(t *testing.T) {
+	for _, tc := range []struct {
+		pool string
+		want []string
+	}{
+		{
+			pool: "",
+			want: []string{
+				"testserver_db_0",
+				"testserver_db_0__ckpt_1234",
+				"testserver_db_7_retained_abcd",
+				"testserver_tmpl_0123456789abcdef",
+			},
+		},
+		{
+			pool: "legacy",
+			want: []string{"testserver_legacy_db_3"},
+		},
+	} {
+		t.Run(tc.pool, func(t *testing.T) {
+			p := &fake.DatabaseProvider{Databases: []string{
+				"testserver_db_0",
+				"testserver_db_0__ckpt_1234",
+				"testserver_db_7_retained_abcd",
+				"testserver_tmpl_0123456789abcdef",
+				"testserver_legacy_db_3",
+				"testserver_mine",
+				"testserver_db_x",
+				"production",
+			}}
+			les := New(p, Config{Name: tc.pool, Size: 1})
+			les.reclaim(context.Background(), nil)
+			if diff := deep.Equal(les.Reclaimed(), tc.want); diff != nil {
+				t.Fatal(diff)
+			}
+			if diff := deep.Equal(p.DropList, tc.want); diff != nil {
+				t.Fatal(diff)
+			}
+			if diff := deep.Equal(p.DropUsersList, tc.want); diff != nil {
+				t.Fatal(diff)
+			}
+			if got, want := les.Stats().Reclaimed, len(tc.want); got != want {
+				t.Fatalf("Got %d reclaimed, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestReclaimFails(t *testing.T) {
+	p := &fake.DatabaseProvider{
+		Databases: []string{"testserver_db_5"},
+		DropErr:   errors.New("Oof!"),
+	}
+	les := New(p, Config{Size: 1})
+	les.reclaim(context.Background(), nil)
+	if got := les.Reclaimed(); len(got) != 0 {
+		t.Fatalf("Got reclaimed %q, want none", got)
+	}
+
+	// The pool starts anyway.
+	p.ListErr = errors.New("Oof!")
+	p.DropErr = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	les.Return(l)
+}
+
+// A provider that records what the pool asks it to reclaim later.
+type laterProvider struct {
+	fake.DatabaseProvider
+	prefix string
+	owned  func(string) bool
+}
+
+func (p *laterProvider) ReclaimLater(prefix string, owned func(name string) bool) {
+	p.prefix, p.owned = prefix, owned
+}
+
+func TestReclaimLater(t *testing.T) {
+	p := &laterProvider{}
+	les := New(p, Config{Name: "legacy", Size: 1})
+	les.reclaim(context.Background(), nil)
+	if got, want := p.prefix, "testserver_legacy_"; got != want {
+		t.Fatalf("Got prefix %q, want %q", got, want)
+	}
+	for name, want := range map[string]bool{
+		"testserver_legacy_db_3":              true,
+		"testserver_legacy_db_3_retained_abc": true,
+		"testserver_legacy_mine":              false,
+	} {
+		if got := p.owned(name); got != want {
+			t.Errorf("Got owned(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
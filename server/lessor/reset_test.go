@@ -0,0 +1,92 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/karagog/clock-go/simulated"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestResetStrategy(t *testing.T) {
+	for _, strategy := range []databaseprovider.ResetStrategy{databaseprovider.Truncate, databaseprovider.RestoreTemplate} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			p := &fake.DatabaseProvider{}
+			c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+			les := New(p, Config{Size: 1, Clock: c, ResetStrategy: strategy})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go les.Run(ctx)
+
+			// The database is created the first time, and reset in place to
+			// its template afterwards.
+			l, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: &Schema{SQL: "CREATE TABLE foo (id INT)"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			name, tmpl := les.Database(l), p.TemplateList[0]
+			les.Return(l)
+			advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+			want := []fake.Reset{{Database: name, Template: tmpl, Strategy: strategy}}
+			if diff := deep.Equal(p.ResetList, want); diff != nil {
+				t.Fatal(diff)
+			}
+			if got, want := len(p.CreateList), 1; got != want {
+				t.Fatalf("Got %d creates, want %d", got, want)
+			}
+
+			// If the database is dropped for a lease of another schema, but
+			// can't be cloned, it's created again rather than reset in place.
+			p.CloneErr = errors.New("Oof!")
+			if _, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: &Schema{SQL: "CREATE TABLE bar (id INT)"}}); err == nil {
+				t.Fatal("Got nil error, want error")
+			}
+			advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+			if diff := deep.Equal(p.ResetList, want); diff != nil {
+				t.Fatal(diff)
+			}
+			if got, want := len(p.CreateList), 2; got != want {
+				t.Fatalf("Got %d creates, want %d", got, want)
+			}
+
+			// Once it's created, it's reset in place again.
+			p.CloneErr = nil
+			if l, err = les.Lease(ctx); err != nil {
+				t.Fatal(err)
+			}
+			les.Return(l)
+			advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+			want = append(want, fake.Reset{Database: name, Strategy: strategy})
+			if diff := deep.Equal(p.ResetList, want); diff != nil {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestResetFailureRecreates(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c, ResetStrategy: databaseprovider.Truncate})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// If truncating fails, the database is recreated.
+	p.ResetErr = errors.New("Oof!")
+	les.Return(l)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	if got, want := len(p.CreateList), 2; got != want {
+		t.Fatalf("Got %d creates, want %d", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package lessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/karagog/clock-go"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// How long retained databases are kept, unless configured otherwise.
+const defaultRetention = time.Hour
+
+// Retained describes a database that was kept after its lease ended, so that
+// a developer can inspect the state in which a test left it.
+type Retained struct {
+	// The name of the retained copy, which is not part of the pool.
+	Name string
+
+	// The database in the pool from which it was moved, and the lease that held it.
+	Database string
+	LeaseID  string
+
+	// When the retained copy will be dropped.
+	ExpireTime time.Time
+
+	// How to connect to the retained copy, as users that only have access to
+	// it, like those of a lease.
+	ConnectionInfo *pb.ConnectionInfo
+}
+
+// ReturnAndRetain is like Return(), but first moves the contents of the
+// leased databases aside, where they are kept for the pool's retention period,
+// e.g. so you can inspect them after a test failed. The databases are then
+// reset as usual, so the pool doesn't shrink.
+//
+// It returns the retained databases, and an error if any of them couldn't be
+// retained. The lease is returned either way.
+func (l *Lessor) ReturnAndRetain(ctx context.Context, lease Lease) ([]Retained, error) {
+	g := l.getGrant(lease)
+	g.mu.Lock() // wait for any checkpoint operation to finish
+	defer g.mu.Unlock()
+	glog.V(2).Infof("ReturnAndRetain called on lease %s of %q", g.info.ID, g.info.Databases)
+	l.end(g)
+	defer l.resetAll(g.info.Databases)
+
+	var ret []Retained
+	for _, name := range g.info.Databases {
+		r := Retained{
+			Name:       retainedName(name, g.info.ID),
+			Database:   name,
+			LeaseID:    g.info.ID,
+			ExpireTime: l.clock.Now().Add(l.retention),
+		}
+		// The tables are moved to the copy, so the database must be
+		// recreated rather than reset in place.
+		l.getDatabase(name).broken = true
+		err := l.provider.RetainDatabase(ctx, name, r.Name)
+		if err == nil {
+			r.ConnectionInfo, err = l.provider.CreateUsers(ctx, r.Name)
+		}
+		if err != nil {
+			// Don't leave a partial copy behind.
+			if err := l.provider.DropUsers(ctx, r.Name); err != nil {
+				glog.Errorf("Error dropping the users of partially retained database %s: %s", r.Name, err)
+			}
+			if err := l.provider.DropDatabase(ctx, r.Name); err != nil {
+				glog.Errorf("Error dropping partially retained database %s: %s", r.Name, err)
+			}
+			return ret, fmt.Errorf("retaining database %s: %w", name, err)
+		}
+		glog.Warningf("Retained database %s of lease %s as %s until %v",
+			name, g.info.ID, r.Name, r.ExpireTime.Format(time.RFC3339))
+		l.mu.Lock()
+		l.retained[r.Name] = r
+		l.mu.Unlock()
+		ret = append(ret, r)
+
+		// Wake up the retention worker, unless it's already due to wake up.
+		select {
+		case l.retainCh <- struct{}{}:
+		default:
+		}
+	}
+	return ret, nil
+}
+
+// Returns the name of the database that retains the contents of the leased one.
+func retainedName(database, leaseID string) string {
+	return fmt.Sprintf("%s_retained_%s", database, leaseID)
+}
+
+// Retained lists the retained databases, ordered by when they expire.
+func (l *Lessor) Retained() []Retained {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ret []Retained
+	for _, r := range l.retained {
+		ret = append(ret, r)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ExpireTime.Before(ret[j].ExpireTime) })
+	return ret
+}
+
+// Drops the retained databases when they expire.
+func (l *Lessor) retentionWorker(ctx context.Context) {
+	var tmr clock.Timer // fires when the next retained database expires
+	var tmrCh <-chan time.Time
+	for {
+		select {
+		case <-l.retainCh:
+		case <-tmrCh:
+		case <-ctx.Done():
+			if tmr != nil {
+				tmr.Stop()
+			}
+			return
+		}
+		next := l.dropExpired(ctx)
+		if tmr != nil {
+			tmr.Stop()
+		}
+		tmr, tmrCh = nil, nil
+		if !next.IsZero() {
+			tmr = l.clock.NewTimer(next.Sub(l.clock.Now()))
+			tmrCh = tmr.C()
+		}
+	}
+}
+
+// Drops the retained databases that have expired, and returns when the next
+// one expires, or zero if there are none left.
+func (l *Lessor) dropExpired(ctx context.Context) time.Time {
+	now := l.clock.Now()
+	var expired []string
+	var next time.Time
+	l.mu.Lock()
+	for name, r := range l.retained {
+		switch {
+		case !r.ExpireTime.After(now):
+			expired = append(expired, name)
+		case next.IsZero() || r.ExpireTime.Before(next):
+			next = r.ExpireTime
+		}
+	}
+	l.mu.Unlock()
+	for _, name := range expired {
+		glog.Infof("Dropping retained database %s", name)
+		if err := l.provider.DropUsers(ctx, name); err != nil {
+			glog.Errorf("Error dropping the users of retained database %s: %s", name, err)
+		}
+		if err := l.provider.DropDatabase(ctx, name); err != nil {
+			glog.Errorf("Error dropping retained database %s: %s", name, err)
+		}
+		l.mu.Lock()
+		delete(l.retained, name)
+		l.mu.Unlock()
+	}
+	return next
+}
@@ -0,0 +1,191 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/karagog/clock-go/simulated"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestReturnAndRetain(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c, Retention: 10 * time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := les.Info(l).ID
+	retained, err := les.ReturnAndRetain(ctx, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Retained{{
+		Name:       "testserver_db_0_retained_" + id,
+		Database:   "testserver_db_0",
+		LeaseID:    id,
+		ExpireTime: c.Now().Add(10 * time.Minute),
+
+		ConnectionInfo: &p.Info,
+	}}
+	if diff := deep.Equal(retained, want); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(les.Retained(), want); diff != nil {
+		t.Fatal(diff)
+	}
+	// The retained database gets its own users, rather than the server's.
+	if got, want := p.CreateUsersList[len(p.CreateUsersList)-1], want[0].Name; got != want {
+		t.Fatalf("Got last users created for %q, want %q", got, want)
+	}
+
+	// The pool doesn't shrink.
+	l, err = les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer les.Return(l)
+	if got, want := les.Stats().Retained, 1; got != want {
+		t.Fatalf("Got %d retained, want %d", got, want)
+	}
+	if diff := deep.Equal(p.RetainList, []fake.Retain{{Database: "testserver_db_0", Retained: want[0].Name}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// The retained database is dropped when it expires.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(les.Retained()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Retained database was never dropped")
+		}
+		c.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := p.DropList[len(p.DropList)-1], want[0].Name; got != want {
+		t.Fatalf("Got last drop of %q, want %q", got, want)
+	}
+	if got, want := p.DropUsersList[len(p.DropUsersList)-1], want[0].Name; got != want {
+		t.Fatalf("Got last users dropped for %q, want %q", got, want)
+	}
+}
+
+func TestReturnAndRetainRecreates(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c, ResetStrategy: databaseprovider.Truncate})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := les.ReturnAndRetain(ctx, l); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tables went to the retained copy, so truncating what's left
+	// wouldn't restore the database.
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	if got, want := len(p.CreateList), 2; got != want {
+		t.Fatalf("Got %d creates, want %d", got, want)
+	}
+	if len(p.ResetList) != 0 {
+		t.Fatalf("Got resets %+v, want none", p.ResetList)
+	}
+
+	// Once it's recreated, it's truncated again.
+	if l, err = les.Lease(ctx); err != nil {
+		t.Fatal(err)
+	}
+	les.Return(l)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	if got, want := len(p.ResetList), 1; got != want {
+		t.Fatalf("Got %d resets, want %d", got, want)
+	}
+}
+
+func TestReturnAndRetainFails(t *testing.T) {
+	p := &fake.DatabaseProvider{RetainErr: errors.New("Oof!")}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := retainedName("testserver_db_0", les.Info(l).ID)
+	if _, err := les.ReturnAndRetain(ctx, l); !errors.Is(err, p.RetainErr) {
+		t.Fatalf("Got error %v, want %v", err, p.RetainErr)
+	}
+	if got := les.Retained(); len(got) != 0 {
+		t.Fatalf("Got retained %v, want none", got)
+	}
+
+	// The lease was returned anyway, and the partial copy was dropped.
+	l, err = les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer les.Return(l)
+	found := false
+	for _, d := range p.DropList {
+		found = found || d == name
+	}
+	if !found {
+		t.Fatalf("Got drops %q, want %q among them", p.DropList, name)
+	}
+}
+
+func TestReturnAndRetainUsersFail(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := retainedName("testserver_db_0", les.Info(l).ID)
+	p.CreateUsersErr = errors.New("Oof!")
+	if _, err := les.ReturnAndRetain(ctx, l); !errors.Is(err, p.CreateUsersErr) {
+		t.Fatalf("Got error %v, want %v", err, p.CreateUsersErr)
+	}
+	if got := les.Retained(); len(got) != 0 {
+		t.Fatalf("Got retained %v, want none", got)
+	}
+
+	// The copy was dropped along with any of its users.
+	deadline := time.Now().Add(5 * time.Second)
+	for les.Stats().Ready != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("The database was never reset")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	droppedUsers, dropped := false, false
+	for _, d := range p.DropUsersList {
+		droppedUsers = droppedUsers || d == name
+	}
+	for _, d := range p.DropList {
+		dropped = dropped || d == name
+	}
+	if !droppedUsers || !dropped {
+		t.Fatalf("Got users dropped for %q and drops %q, want %q among both", p.DropUsersList, p.DropList, name)
+	}
+}
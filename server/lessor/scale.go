@@ -0,0 +1,141 @@
+package lessor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/karagog/clock-go"
+)
+
+const (
+	// How long a database beyond the pool's minimum size may sit idle before
+	// it's dropped, unless configured.
+	defaultIdleTimeout = 5 * time.Minute
+
+	// How many of the latest scaling decisions to remember.
+	maxScaleEvents = 20
+)
+
+// ScaleEvent records a decision to grow or shrink the pool.
+type ScaleEvent struct {
+	Time time.Time
+
+	// The number of databases in the pool before and after.
+	From, To int
+
+	// Why the pool was scaled, for humans.
+	Reason string
+}
+
+// Adds databases to the pool, up to its maximum size, when there aren't
+// enough ready or on the way to serve the clients in line. It returns the new
+// databases, which the caller must hand to the reset workers.
+func (l *Lessor) growLocked() []string {
+	if len(l.databases) < l.numDB {
+		return nil // Run() hasn't created the pool yet
+	}
+	demand := 0
+	for _, w := range l.queue {
+		demand += w.count
+	}
+	supply := len(l.ready)
+	for _, db := range l.databases {
+		if db.state == resetting {
+			supply++
+		}
+	}
+	n := demand - supply
+	if room := l.maxSize - len(l.databases); n > room {
+		n = room
+	}
+	if n <= 0 {
+		return nil
+	}
+	var names []string
+	for i := 0; i < n; i++ {
+		names = append(names, l.addDatabaseLocked())
+	}
+	l.recordScaleLocked(len(l.databases)-n, fmt.Sprintf("%d clients waiting for %d databases", len(l.queue), demand))
+	return names
+}
+
+// Records that the pool was scaled from the given size to its current size.
+func (l *Lessor) recordScaleLocked(from int, reason string) {
+	e := ScaleEvent{Time: l.clock.Now(), From: from, To: len(l.databases), Reason: reason}
+	glog.Infof("Scaling pool %q from %d to %d databases: %s", l.name, e.From, e.To, e.Reason)
+	l.scaleEvents = append(l.scaleEvents, e)
+	if len(l.scaleEvents) > maxScaleEvents {
+		l.scaleEvents = l.scaleEvents[len(l.scaleEvents)-maxScaleEvents:]
+	}
+}
+
+// ScaleEvents returns the latest decisions to grow or shrink the pool, oldest first.
+func (l *Lessor) ScaleEvents() []ScaleEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ScaleEvent(nil), l.scaleEvents...)
+}
+
+// Shrinks the pool back towards its minimum size when databases sit idle.
+func (l *Lessor) scaleWorker(ctx context.Context) {
+	var tmr clock.Timer // fires when the next idle database times out
+	var tmrCh <-chan time.Time
+	for {
+		select {
+		case <-l.idleCh:
+		case <-tmrCh:
+		case <-ctx.Done():
+			if tmr != nil {
+				tmr.Stop()
+			}
+			return
+		}
+		next := l.shrink(ctx)
+		if tmr != nil {
+			tmr.Stop()
+		}
+		tmr, tmrCh = nil, nil
+		if !next.IsZero() {
+			tmr = l.clock.NewTimer(next.Sub(l.clock.Now()))
+			tmrCh = tmr.C()
+		}
+	}
+}
+
+// Drops the databases that have been idle for too long, as long as the pool
+// is above its minimum size, and returns when the next one times out, or zero
+// if there is nothing to wait for.
+func (l *Lessor) shrink(ctx context.Context) time.Time {
+	now := l.clock.Now()
+	var idle []string
+	var next time.Time
+	l.mu.Lock()
+	from := len(l.databases)
+	// The ready list is ordered by when the databases became ready, so the
+	// first one has been idle the longest.
+	for len(l.databases) > l.numDB && len(l.ready) > 0 {
+		name := l.ready[0]
+		timeout := l.databases[name].readySince.Add(l.idleTimeout)
+		if timeout.After(now) {
+			next = timeout
+			break
+		}
+		l.ready = l.ready[1:]
+		delete(l.databases, name)
+		l.journalLocked(name)
+		idle = append(idle, name)
+	}
+	if len(idle) > 0 {
+		l.recordScaleLocked(from, fmt.Sprintf("%d databases idle for %s", len(idle), l.idleTimeout))
+	}
+	l.mu.Unlock()
+
+	for _, name := range idle {
+		if err := l.provider.DropDatabase(ctx, name); err != nil {
+			glog.Errorf("Error dropping idle database %s: %s", name, err)
+		}
+	}
+	return next
+}
@@ -0,0 +1,94 @@
+package lessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestPoolGrowsAndShrinks(t *testing.T) {
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(&fake.DatabaseProvider{}, Config{Size: 1, MaxSize: 3, IdleTimeout: time.Minute, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	held, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Three clients line up, and the pool grows for two of them. They may be
+	// served as soon as they are in line, so they can't be waited on in order.
+	results := make(chan waitResult, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			l, err := les.Lease(ctx)
+			results <- waitResult{l, err}
+		}()
+	}
+	leases := []Lease{held}
+	for i := 0; i < 2; i++ {
+		res := <-results
+		if res.err != nil {
+			t.Fatal(res.err)
+		}
+		leases = append(leases, res.lease)
+	}
+	for les.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if st := les.Stats(); st.Total != 3 || st.Leased != 3 {
+		t.Fatalf("Got stats %+v, want 3 databases, all leased", st)
+	}
+	if got := les.ScaleEvents(); len(got) == 0 || got[len(got)-1].To != 3 {
+		t.Fatalf("Got scale events %+v, want growth to 3", got)
+	}
+
+	// The last client is served once a lease is returned.
+	les.Return(leases[0])
+	res := <-results
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	leases = append(leases[1:], res.lease)
+	for _, l := range leases {
+		les.Return(l)
+	}
+
+	// Once the databases have been idle for a while, the pool shrinks back.
+	advanceUntil(t, c, func() bool { return les.Stats().Total == 1 })
+	events := les.ScaleEvents()
+	if e := events[len(events)-1]; e.From != 3 || e.To != 1 {
+		t.Fatalf("Got last scale event %+v, want shrinking from 3 to 1", e)
+	}
+
+	// The remaining database still works.
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	les.Return(l)
+}
+
+func TestPoolDoesNotShrinkBelowSize(t *testing.T) {
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(&fake.DatabaseProvider{}, Config{Size: 2, MaxSize: 4, IdleTimeout: time.Minute, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 2 })
+	c.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if st := les.Stats(); st.Total != 2 {
+		t.Fatalf("Got %d databases, want 2", st.Total)
+	}
+	if got := les.ScaleEvents(); len(got) != 0 {
+		t.Fatalf("Got scale events %+v, want none", got)
+	}
+}
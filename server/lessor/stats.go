@@ -0,0 +1,91 @@
+package lessor
+
+import "github.com/karagog/db-provider/server/lessor/databaseprovider"
+
+// The lifecycle state of a database in the pool.
+type dbState int
+
+const (
+	resetting dbState = iota // being (re)created, and not available yet
+	ready                    // available to be leased
+	leased                   // held by a client
+	failed                   // waiting to retry after an error while resetting
+)
+
+// Stats are a snapshot of a pool's counters.
+type Stats struct {
+	Total     int // all the databases in the pool
+	Ready     int // available to be leased right now
+	Leased    int // held by clients
+	Resetting int // being (re)created
+	Retrying  int // waiting to retry after failing to reset
+
+	// How many databases were quarantined because they kept failing to reset.
+	// These were replaced, so they are not part of the pool.
+	Failed int
+
+	// Whether fewer databases are usable than the configured minimum.
+	LowCapacity bool
+
+	// How many databases are unusable because their server is unavailable.
+	// They are still part of the pool, and are replaced when they're reset.
+	Unavailable int
+
+	// How many clients are waiting for a database.
+	Waiting int
+
+	// How many databases were kept for debugging after their lease ended.
+	// These are not part of the pool.
+	Retained int
+
+	// How many databases left over from a previous run were dropped at startup.
+	Reclaimed int
+
+	// How many databases left over from a previous run were recovered from the
+	// journal at startup, rather than dropped.
+	Recovered int
+
+	// True until every database in the pool has been created for the first
+	// time (whether it succeeded or not).
+	Starting bool
+}
+
+// Stats returns a snapshot of the pool's counters.
+func (l *Lessor) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := Stats{
+		Total:       len(l.databases),
+		Waiting:     len(l.queue),
+		Retained:    len(l.retained),
+		Reclaimed:   len(l.reclaimed),
+		Recovered:   l.recovered,
+		Failed:      len(l.quarantined),
+		LowCapacity: l.lowCapacity,
+		Starting:    l.initialized < l.numDB,
+	}
+	a, _ := l.provider.(databaseprovider.Availability)
+	for name, db := range l.databases {
+		if a != nil && !a.Available(name) {
+			s.Unavailable++
+		}
+		switch db.state {
+		case ready:
+			s.Ready++
+		case leased:
+			s.Leased++
+		case resetting:
+			s.Resetting++
+		case failed:
+			s.Retrying++
+		}
+	}
+	return s
+}
+
+// Updates the state of the database.
+func (l *Lessor) setState(name string, state dbState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.databases[name].state = state
+}
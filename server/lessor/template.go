@@ -0,0 +1,107 @@
+package lessor
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+// Schema describes the schema with which to pre-load a leased database.
+//
+// The lessor builds a template database once per schema, and clones leased
+// databases from the template, which is much faster than running the schema
+// statements for every lease.
+type Schema struct {
+	// Hash identifies the schema. If empty, it is the SHA-256 of the SQL.
+	Hash string
+
+	// SQL statements that create the schema. They may be omitted if the
+	// template with the same hash has already been built.
+	SQL string
+}
+
+// ErrUnknownSchema means the schema was given only by its hash, but there is
+// no template for it yet.
+var ErrUnknownSchema = errors.New("unknown schema hash, the SQL is required to build it")
+
+// SchemaError means the schema template could not be built, e.g. due to invalid SQL.
+type SchemaError struct {
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("error building schema template: %v", e.Err)
+}
+
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+// A template database for a schema.
+type template struct {
+	name  string        // const
+	built chan struct{} // closed when the build is done
+	err   error         // valid after the build is done
+}
+
+// Returns the name of the template database for the schema, which is built
+// the first time it's requested. Concurrent requests for the same schema
+// wait for the same build.
+func (l *Lessor) template(ctx context.Context, s *Schema) (string, error) {
+	hash := s.Hash
+	if hash == "" {
+		hash = fmt.Sprintf("%x", sha256.Sum256([]byte(s.SQL)))
+	}
+	l.mu.Lock()
+	t, ok := l.templates[hash]
+	if !ok {
+		if s.SQL == "" {
+			l.mu.Unlock()
+			return "", ErrUnknownSchema
+		}
+		// The hash is client-supplied, so hash it again to get a valid name.
+		sum := sha256.Sum256([]byte(hash))
+		t = &template{
+			name:  fmt.Sprintf("%stmpl_%x", l.Prefix(), sum[:8]),
+			built: make(chan struct{}),
+		}
+		l.templates[hash] = t
+
+		// Build detached from the request, so other requests can use it even
+		// if this one is cancelled.
+		go l.buildTemplate(t, hash, s.SQL)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-t.built:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if t.err != nil {
+		return "", &SchemaError{t.err}
+	}
+	return t.name, nil
+}
+
+func (l *Lessor) buildTemplate(t *template, hash, sql string) {
+	defer close(t.built)
+	glog.Infof("Building schema template %s", t.name)
+	ctx := context.Background()
+	if t.err = l.provider.DropDatabase(ctx, t.name); t.err == nil {
+		t.err = l.provider.CreateTemplate(ctx, t.name, sql)
+	}
+	if t.err == nil {
+		return
+	}
+	glog.Errorf("Error building schema template %s: %s", t.name, t.err)
+
+	// Forget about the failed template, so that it can be retried.
+	l.mu.Lock()
+	delete(l.templates, hash)
+	l.mu.Unlock()
+	if err := l.provider.DropDatabase(ctx, t.name); err != nil {
+		glog.Errorf("Error dropping failed template %s: %s", t.name, err)
+	}
+}
@@ -0,0 +1,110 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+func TestLeaseWithSchema(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	schema := &Schema{SQL: "CREATE TABLE foo (id INT)"}
+	l, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The template was built, and the database was cloned from it.
+	if got, want := len(p.TemplateList), 1; got != want {
+		t.Fatalf("Built %v templates, want %v", got, want)
+	}
+	tmpl := p.TemplateList[0]
+	if diff := deep.Equal(p.CloneList, []fake.Clone{{Template: tmpl, Name: les.Database(l)}}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// When the lease is returned, the database is recreated from the same template.
+	p.CloneList = nil
+	les.Return(l)
+	if l, err = les.LeaseWithOptions(ctx, LeaseOptions{Schema: schema}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(p.CloneList, []fake.Clone{{Template: tmpl, Name: les.Database(l)}}); diff != nil {
+		t.Fatal(diff)
+	}
+	if got, want := len(p.TemplateList), 1; got != want {
+		t.Fatalf("Built %v templates, want %v", got, want)
+	}
+
+	// A lease without a schema gets a fresh empty database, instead of one
+	// cloned from the template.
+	les.Return(l)
+	p.CreateList = nil
+	if l, err = les.Lease(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(p.CreateList, []string{les.Database(l)}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestLeaseWithSchemaHash(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	// The template has not been built yet, so we need the SQL.
+	if _, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: &Schema{Hash: "v1"}}); err != ErrUnknownSchema {
+		t.Fatalf("Got error %v, want %v", err, ErrUnknownSchema)
+	}
+
+	l, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: &Schema{Hash: "v1", SQL: "CREATE TABLE foo (id INT)"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	les.Return(l)
+
+	// Now we can refer to it by hash only.
+	if _, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: &Schema{Hash: "v1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(p.TemplateList), 1; got != want {
+		t.Fatalf("Built %v templates, want %v", got, want)
+	}
+}
+
+func TestLeaseWithInvalidSchema(t *testing.T) {
+	p := &fake.DatabaseProvider{TemplateErr: fmt.Errorf("Oof!")}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	schema := &Schema{SQL: "CREATE TABLE"}
+	_, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: schema})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Got error %v, want SchemaError", err)
+	}
+
+	// The failed template is forgotten, so it's built again next time.
+	p.TemplateErr = nil
+	if _, err := les.LeaseWithOptions(ctx, LeaseOptions{Schema: schema}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(p.TemplateList), 2; got != want {
+		t.Fatalf("Built %v templates, want %v", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package lessor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+)
+
+// A database that was reset, but isn't clean.
+type verifyError struct {
+	err error
+}
+
+func (e *verifyError) Error() string {
+	return fmt.Sprintf("verification failed: %s", e.err)
+}
+
+func (e *verifyError) Unwrap() error { return e.err }
+
+// Checks that the database is clean before it becomes ready, if configured
+// and the provider can.
+func (l *Lessor) verify(ctx context.Context, name string, db *database) error {
+	v, ok := l.provider.(databaseprovider.Verifier)
+	if !l.verifyReset || !ok {
+		return nil
+	}
+	if err := v.VerifyDatabase(ctx, name, db.template, l.resetStrategy); err != nil {
+		return &verifyError{err}
+	}
+	return nil
+}
+
+// Quarantines a database that failed verification right away, since resetting
+// it again would probably leave it the same. The replacements are created
+// with backoff while databases keep failing, e.g. if the server is
+// misconfigured.
+func (l *Lessor) verifyFailed(ctx context.Context, name string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	db := l.databases[name]
+	db.state = failed
+	db.failures++
+	l.verifyFailures++
+	glog.Errorf("Quarantining database %s of pool %q: %s", name, l.name, err)
+	l.quarantineLocked(ctx, name, err, l.backoff(l.verifyFailures))
+}
@@ -0,0 +1,102 @@
+package lessor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+
+	"github.com/karagog/db-provider/server/lessor/databaseprovider"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+)
+
+// A provider whose databases fail verification until told otherwise.
+type dirtyProvider struct {
+	fake.DatabaseProvider
+
+	mu  sync.Mutex
+	err error
+}
+
+func (p *dirtyProvider) VerifyDatabase(ctx context.Context, database, template string, strategy databaseprovider.ResetStrategy) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *dirtyProvider) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func TestVerifyFailed(t *testing.T) {
+	p := &dirtyProvider{err: errors.New("database has 1 views")}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c, VerifyResets: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	// The database is quarantined on its first failure.
+	advanceUntil(t, c, func() bool { return len(les.Quarantined()) > 0 })
+	q := les.Quarantined()[0]
+	if q.Name != "testserver_db_0" || q.Attempts != 1 || !strings.Contains(q.Error, "verification failed") {
+		t.Fatalf("Got quarantined %+v, want testserver_db_0 after failing verification", q)
+	}
+
+	// Once the databases are clean, the replacement is leased.
+	p.setErr(nil)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer les.Return(l)
+	if name := les.Database(l); name == "testserver_db_0" {
+		t.Fatalf("Got quarantined database %q leased", name)
+	}
+}
+
+func TestVerifyDisabled(t *testing.T) {
+	p := &fake.DatabaseProvider{VerifyErr: errors.New("Oof!")}
+	les := New(p, Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer les.Return(l)
+	if len(p.VerifyList) != 0 {
+		t.Fatalf("Got verifications %+v, want none", p.VerifyList)
+	}
+}
+
+func TestVerifyStrategy(t *testing.T) {
+	p := &fake.DatabaseProvider{}
+	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
+	les := New(p, Config{Size: 1, Clock: c, VerifyResets: true, ResetStrategy: databaseprovider.Truncate})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go les.Run(ctx)
+
+	// The provider knows what the reset kept, so it checks accordingly.
+	l, err := les.Lease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := les.Database(l)
+	les.Return(l)
+	advanceUntil(t, c, func() bool { return les.Stats().Ready == 1 })
+	want := fake.Verify{Database: name, Strategy: databaseprovider.Truncate}
+	if got := p.VerifyList; len(got) != 2 || got[1] != want {
+		t.Fatalf("Got verifications %+v, want the second %+v", got, want)
+	}
+}
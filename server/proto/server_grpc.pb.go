@@ -28,6 +28,29 @@ type IntegrationTestClient interface {
 	//
 	// See protobuf messages for protocol details.
 	GetDatabaseInstance(ctx context.Context, opts ...grpc.CallOption) (IntegrationTest_GetDatabaseInstanceClient, error)
+	// AcquireLease is an alternative to GetDatabaseInstance for clients that
+	// can't keep a stream open, e.g. shell scripts. It blocks until the lease
+	// is granted, and returns a token with which to renew and release it.
+	//
+	// The lease expires unless it is renewed within the heartbeat TTL, after
+	// which the databases are given to another requestor.
+	AcquireLease(ctx context.Context, in *AcquireLeaseRequest, opts ...grpc.CallOption) (*AcquireLeaseResponse, error)
+	// RenewLease keeps a lease from AcquireLease alive for another heartbeat TTL.
+	// It fails with NOT_FOUND if the lease has already expired.
+	RenewLease(ctx context.Context, in *RenewLeaseRequest, opts ...grpc.CallOption) (*RenewLeaseResponse, error)
+	// ReleaseLease gives back a lease from AcquireLease.
+	ReleaseLease(ctx context.Context, in *ReleaseLeaseRequest, opts ...grpc.CallOption) (*ReleaseLeaseResponse, error)
+	// Checkpoint saves the state of a held lease's databases under a name, so
+	// you can roll back to it later, e.g. between sub-tests that share an
+	// expensive setup. The checkpoints are dropped when the lease ends. Only
+	// the holder can take them, with the lease's token.
+	Checkpoint(ctx context.Context, in *CheckpointRequest, opts ...grpc.CallOption) (*CheckpointResponse, error)
+	// Rollback restores a held lease's databases to a checkpoint.
+	Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*RollbackResponse, error)
+	// DumpDatabase streams a SQL dump of the schema and data of one of a held
+	// lease's databases, e.g. to save as a CI artifact before releasing it.
+	// Only the holder can dump them, with the lease's token.
+	DumpDatabase(ctx context.Context, in *DumpDatabaseRequest, opts ...grpc.CallOption) (IntegrationTest_DumpDatabaseClient, error)
 }
 
 type integrationTestClient struct {
@@ -78,6 +101,83 @@ func (x *integrationTestGetDatabaseInstanceClient) Recv() (*GetDatabaseInstanceR
 	return m, nil
 }
 
+func (c *integrationTestClient) AcquireLease(ctx context.Context, in *AcquireLeaseRequest, opts ...grpc.CallOption) (*AcquireLeaseResponse, error) {
+	out := new(AcquireLeaseResponse)
+	err := c.cc.Invoke(ctx, "/server.IntegrationTest/AcquireLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *integrationTestClient) RenewLease(ctx context.Context, in *RenewLeaseRequest, opts ...grpc.CallOption) (*RenewLeaseResponse, error) {
+	out := new(RenewLeaseResponse)
+	err := c.cc.Invoke(ctx, "/server.IntegrationTest/RenewLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *integrationTestClient) ReleaseLease(ctx context.Context, in *ReleaseLeaseRequest, opts ...grpc.CallOption) (*ReleaseLeaseResponse, error) {
+	out := new(ReleaseLeaseResponse)
+	err := c.cc.Invoke(ctx, "/server.IntegrationTest/ReleaseLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *integrationTestClient) Checkpoint(ctx context.Context, in *CheckpointRequest, opts ...grpc.CallOption) (*CheckpointResponse, error) {
+	out := new(CheckpointResponse)
+	err := c.cc.Invoke(ctx, "/server.IntegrationTest/Checkpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *integrationTestClient) Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*RollbackResponse, error) {
+	out := new(RollbackResponse)
+	err := c.cc.Invoke(ctx, "/server.IntegrationTest/Rollback", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *integrationTestClient) DumpDatabase(ctx context.Context, in *DumpDatabaseRequest, opts ...grpc.CallOption) (IntegrationTest_DumpDatabaseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &IntegrationTest_ServiceDesc.Streams[1], "/server.IntegrationTest/DumpDatabase", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &integrationTestDumpDatabaseClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type IntegrationTest_DumpDatabaseClient interface {
+	Recv() (*DumpDatabaseResponse, error)
+	grpc.ClientStream
+}
+
+type integrationTestDumpDatabaseClient struct {
+	grpc.ClientStream
+}
+
+func (x *integrationTestDumpDatabaseClient) Recv() (*DumpDatabaseResponse, error) {
+	m := new(DumpDatabaseResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // IntegrationTestServer is the server API for IntegrationTest service.
 // All implementations must embed UnimplementedIntegrationTestServer
 // for forward compatibility
@@ -92,6 +192,29 @@ type IntegrationTestServer interface {
 	//
 	// See protobuf messages for protocol details.
 	GetDatabaseInstance(IntegrationTest_GetDatabaseInstanceServer) error
+	// AcquireLease is an alternative to GetDatabaseInstance for clients that
+	// can't keep a stream open, e.g. shell scripts. It blocks until the lease
+	// is granted, and returns a token with which to renew and release it.
+	//
+	// The lease expires unless it is renewed within the heartbeat TTL, after
+	// which the databases are given to another requestor.
+	AcquireLease(context.Context, *AcquireLeaseRequest) (*AcquireLeaseResponse, error)
+	// RenewLease keeps a lease from AcquireLease alive for another heartbeat TTL.
+	// It fails with NOT_FOUND if the lease has already expired.
+	RenewLease(context.Context, *RenewLeaseRequest) (*RenewLeaseResponse, error)
+	// ReleaseLease gives back a lease from AcquireLease.
+	ReleaseLease(context.Context, *ReleaseLeaseRequest) (*ReleaseLeaseResponse, error)
+	// Checkpoint saves the state of a held lease's databases under a name, so
+	// you can roll back to it later, e.g. between sub-tests that share an
+	// expensive setup. The checkpoints are dropped when the lease ends. Only
+	// the holder can take them, with the lease's token.
+	Checkpoint(context.Context, *CheckpointRequest) (*CheckpointResponse, error)
+	// Rollback restores a held lease's databases to a checkpoint.
+	Rollback(context.Context, *RollbackRequest) (*RollbackResponse, error)
+	// DumpDatabase streams a SQL dump of the schema and data of one of a held
+	// lease's databases, e.g. to save as a CI artifact before releasing it.
+	// Only the holder can dump them, with the lease's token.
+	DumpDatabase(*DumpDatabaseRequest, IntegrationTest_DumpDatabaseServer) error
 	mustEmbedUnimplementedIntegrationTestServer()
 }
 
@@ -105,6 +228,24 @@ func (UnimplementedIntegrationTestServer) GetStatus(context.Context, *GetStatusR
 func (UnimplementedIntegrationTestServer) GetDatabaseInstance(IntegrationTest_GetDatabaseInstanceServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetDatabaseInstance not implemented")
 }
+func (UnimplementedIntegrationTestServer) AcquireLease(context.Context, *AcquireLeaseRequest) (*AcquireLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcquireLease not implemented")
+}
+func (UnimplementedIntegrationTestServer) RenewLease(context.Context, *RenewLeaseRequest) (*RenewLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewLease not implemented")
+}
+func (UnimplementedIntegrationTestServer) ReleaseLease(context.Context, *ReleaseLeaseRequest) (*ReleaseLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseLease not implemented")
+}
+func (UnimplementedIntegrationTestServer) Checkpoint(context.Context, *CheckpointRequest) (*CheckpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Checkpoint not implemented")
+}
+func (UnimplementedIntegrationTestServer) Rollback(context.Context, *RollbackRequest) (*RollbackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rollback not implemented")
+}
+func (UnimplementedIntegrationTestServer) DumpDatabase(*DumpDatabaseRequest, IntegrationTest_DumpDatabaseServer) error {
+	return status.Errorf(codes.Unimplemented, "method DumpDatabase not implemented")
+}
 func (UnimplementedIntegrationTestServer) mustEmbedUnimplementedIntegrationTestServer() {}
 
 // UnsafeIntegrationTestServer may be embedded to opt out of forward compatibility for this service.
@@ -162,6 +303,117 @@ func (x *integrationTestGetDatabaseInstanceServer) Recv() (*GetDatabaseInstanceR
 	return m, nil
 }
 
+func _IntegrationTest_AcquireLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcquireLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntegrationTestServer).AcquireLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.IntegrationTest/AcquireLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntegrationTestServer).AcquireLease(ctx, req.(*AcquireLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntegrationTest_RenewLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntegrationTestServer).RenewLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.IntegrationTest/RenewLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntegrationTestServer).RenewLease(ctx, req.(*RenewLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntegrationTest_ReleaseLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntegrationTestServer).ReleaseLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.IntegrationTest/ReleaseLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntegrationTestServer).ReleaseLease(ctx, req.(*ReleaseLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntegrationTest_Checkpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntegrationTestServer).Checkpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.IntegrationTest/Checkpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntegrationTestServer).Checkpoint(ctx, req.(*CheckpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntegrationTest_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntegrationTestServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.IntegrationTest/Rollback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntegrationTestServer).Rollback(ctx, req.(*RollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntegrationTest_DumpDatabase_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DumpDatabaseRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IntegrationTestServer).DumpDatabase(m, &integrationTestDumpDatabaseServer{stream})
+}
+
+type IntegrationTest_DumpDatabaseServer interface {
+	Send(*DumpDatabaseResponse) error
+	grpc.ServerStream
+}
+
+type integrationTestDumpDatabaseServer struct {
+	grpc.ServerStream
+}
+
+func (x *integrationTestDumpDatabaseServer) Send(m *DumpDatabaseResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // IntegrationTest_ServiceDesc is the grpc.ServiceDesc for IntegrationTest service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -173,6 +425,26 @@ var IntegrationTest_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetStatus",
 			Handler:    _IntegrationTest_GetStatus_Handler,
 		},
+		{
+			MethodName: "AcquireLease",
+			Handler:    _IntegrationTest_AcquireLease_Handler,
+		},
+		{
+			MethodName: "RenewLease",
+			Handler:    _IntegrationTest_RenewLease_Handler,
+		},
+		{
+			MethodName: "ReleaseLease",
+			Handler:    _IntegrationTest_ReleaseLease_Handler,
+		},
+		{
+			MethodName: "Checkpoint",
+			Handler:    _IntegrationTest_Checkpoint_Handler,
+		},
+		{
+			MethodName: "Rollback",
+			Handler:    _IntegrationTest_Rollback_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -181,6 +453,141 @@ var IntegrationTest_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "DumpDatabase",
+			Handler:       _IntegrationTest_DumpDatabase_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/proto/server.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	// ListLeases lists the active leases in all pools.
+	ListLeases(ctx context.Context, in *ListLeasesRequest, opts ...grpc.CallOption) (*ListLeasesResponse, error)
+	// RevokeLease forcibly ends a lease. The holder's GetDatabaseInstance
+	// stream ends with ABORTED, and the database is reset and goes back to the
+	// pool.
+	RevokeLease(ctx context.Context, in *RevokeLeaseRequest, opts ...grpc.CallOption) (*RevokeLeaseResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListLeases(ctx context.Context, in *ListLeasesRequest, opts ...grpc.CallOption) (*ListLeasesResponse, error) {
+	out := new(ListLeasesResponse)
+	err := c.cc.Invoke(ctx, "/server.Admin/ListLeases", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RevokeLease(ctx context.Context, in *RevokeLeaseRequest, opts ...grpc.CallOption) (*RevokeLeaseResponse, error) {
+	out := new(RevokeLeaseResponse)
+	err := c.cc.Invoke(ctx, "/server.Admin/RevokeLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	// ListLeases lists the active leases in all pools.
+	ListLeases(context.Context, *ListLeasesRequest) (*ListLeasesResponse, error)
+	// RevokeLease forcibly ends a lease. The holder's GetDatabaseInstance
+	// stream ends with ABORTED, and the database is reset and goes back to the
+	// pool.
+	RevokeLease(context.Context, *RevokeLeaseRequest) (*RevokeLeaseResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) ListLeases(context.Context, *ListLeasesRequest) (*ListLeasesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLeases not implemented")
+}
+func (UnimplementedAdminServer) RevokeLease(context.Context, *RevokeLeaseRequest) (*RevokeLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeLease not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_ListLeases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLeasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListLeases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.Admin/ListLeases",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListLeases(ctx, req.(*ListLeasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RevokeLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RevokeLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.Admin/RevokeLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RevokeLease(ctx, req.(*RevokeLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "server.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListLeases",
+			Handler:    _Admin_ListLeases_Handler,
+		},
+		{
+			MethodName: "RevokeLease",
+			Handler:    _Admin_RevokeLease_Handler,
+		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "server/proto/server.proto",
 }
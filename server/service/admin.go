@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/karagog/db-provider/server/lessor"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// This file implements the Admin service.
+
+func (s *Service) ListLeases(ctx context.Context, _ *pb.ListLeasesRequest) (*pb.ListLeasesResponse, error) {
+	resp := &pb.ListLeasesResponse{}
+	for _, l := range s.readyLessors() {
+		resp.Leases = append(resp.Leases, leaseInfos(l)...)
+	}
+	sort.Slice(resp.Leases, func(i, j int) bool {
+		return resp.Leases[i].GrantTime.AsTime().Before(resp.Leases[j].GrantTime.AsTime())
+	})
+	return resp, nil
+}
+
+func (s *Service) RevokeLease(ctx context.Context, req *pb.RevokeLeaseRequest) (*pb.RevokeLeaseResponse, error) {
+	if (req.LeaseId == "") == (req.Database == "") {
+		return nil, status.Error(codes.InvalidArgument, "set exactly one of lease_id or database")
+	}
+	for _, l := range s.readyLessors() {
+		for _, info := range l.Leases() {
+			if info.ID != req.LeaseId && !contains(info.Databases, req.Database) {
+				continue
+			}
+			if err := l.Revoke(info.ID); err != nil {
+				break // it was returned in the meantime
+			}
+			return &pb.RevokeLeaseResponse{}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, lessor.ErrNoSuchLease.Error())
+}
+
+// Returns the active leases of the pool.
+func leaseInfos(l *lessor.Lessor) []*pb.LeaseInfo {
+	var ret []*pb.LeaseInfo
+	for _, info := range l.Leases() {
+		ret = append(ret, &pb.LeaseInfo{
+			Id:             info.ID,
+			Pool:           l.Name(),
+			Database:       info.Database,
+			Databases:      info.Databases,
+			GrantTime:      timestamppb.New(info.GrantTime),
+			WaitDuration:   durationpb.New(info.Wait),
+			ClientMetadata: info.Metadata,
+			ClientInfo:     info.Client,
+		})
+	}
+	return ret
+}
+
+// Returns true if the list contains the string.
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the lessors, or none if they haven't been set yet.
+func (s *Service) readyLessors() map[string]*lessor.Lessor {
+	select {
+	case <-s.initDone:
+		return s.lessors
+	default:
+		return nil
+	}
+}
+
+// Returns what we know about the client from the request context.
+func clientMetadata(ctx context.Context) map[string]string {
+	ret := make(map[string]string)
+	if p, ok := peer.FromContext(ctx); ok {
+		ret["peer"] = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			ret["user_agent"] = ua[0]
+		}
+	}
+	return ret
+}
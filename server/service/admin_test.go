@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+func TestListAndRevokeLeases(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	conn, err := grpc.Dial(server.serviceAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := pb.NewAdminClient(conn)
+	ctx := context.Background()
+
+	// Nobody holds a lease yet.
+	resp, err := admin.ListLeases(ctx, &pb.ListLeasesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Leases) != 0 {
+		t.Fatalf("Got leases %v, want none", resp.Leases)
+	}
+
+	// A client acquires a lease.
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	client := &pb.ClientInfo{TestName: "TestFoo", Pid: 123}
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{ClientInfo: client}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	granted := c.GetResponse("lease available", t)
+
+	if resp, err = admin.ListLeases(ctx, &pb.ListLeasesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(resp.Leases), 1; got != want {
+		t.Fatalf("Got %v leases, want %v", got, want)
+	}
+	info := resp.Leases[0]
+	if got, want := info.Id, granted.LeaseId; got != want {
+		t.Errorf("Got lease ID %q, want %q", got, want)
+	}
+	if got, want := info.Database, "testserver_db_0"; got != want {
+		t.Errorf("Got database %q, want %q", got, want)
+	}
+	if got, want := info.GrantTime.AsTime(), server.clock.Now(); !got.Equal(want) {
+		t.Errorf("Got grant time %v, want %v", got, want)
+	}
+	if info.ClientMetadata["peer"] == "" {
+		t.Errorf("Got no peer in the client metadata: %v", info.ClientMetadata)
+	}
+	if diff := deep.Equal(info.ClientInfo, client); diff != nil {
+		t.Error(diff)
+	}
+
+	// The lease is also reported in the status, if asked for.
+	statusResp, err := server.service.GetStatus(ctx, &pb.GetStatusRequest{IncludeLeases: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(statusResp.Pools[0].Leases, resp.Leases); diff != nil {
+		t.Error(diff)
+	}
+
+	// Revoke the lease, which ends the client's stream.
+	if _, err := admin.RevokeLease(ctx, &pb.RevokeLeaseRequest{Database: info.Database}); err != nil {
+		t.Fatal(err)
+	}
+	err = c.GetError("lease revoked", t)
+	if got, want := status.Code(err), codes.Aborted; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+
+	// The database goes back to the pool.
+	leaseCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := server.lessor.Lease(leaseCtx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokeLeaseErrors(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	for _, tc := range []struct {
+		name string
+		req  *pb.RevokeLeaseRequest
+		want codes.Code
+	}{
+		{"empty", &pb.RevokeLeaseRequest{}, codes.InvalidArgument},
+		{"both", &pb.RevokeLeaseRequest{LeaseId: "a", Database: "b"}, codes.InvalidArgument},
+		{"unknown lease", &pb.RevokeLeaseRequest{LeaseId: "a"}, codes.NotFound},
+		{"unknown database", &pb.RevokeLeaseRequest{Database: "b"}, codes.NotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := server.service.RevokeLease(context.Background(), tc.req)
+			if got := status.Code(err); got != tc.want {
+				t.Fatalf("Got code %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
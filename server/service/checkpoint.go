@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/karagog/db-provider/server/lessor"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// This file implements checkpoints of held leases.
+
+func (s *Service) Checkpoint(ctx context.Context, req *pb.CheckpointRequest) (*pb.CheckpointResponse, error) {
+	les, lease, err := s.checkpointLease(req.Token, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := les.Checkpoint(ctx, lease, req.Name); err != nil {
+		return nil, checkpointStatus(err)
+	}
+	return &pb.CheckpointResponse{}, nil
+}
+
+func (s *Service) Rollback(ctx context.Context, req *pb.RollbackRequest) (*pb.RollbackResponse, error) {
+	les, lease, err := s.checkpointLease(req.Token, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := les.Rollback(ctx, lease, req.Name); err != nil {
+		return nil, checkpointStatus(err)
+	}
+	return &pb.RollbackResponse{}, nil
+}
+
+// Validates a checkpoint request and returns the lease to which it applies.
+func (s *Service) checkpointLease(token, name string) (*lessor.Lessor, lessor.Lease, error) {
+	if name == "" {
+		return nil, nil, status.Error(codes.InvalidArgument, "missing checkpoint name")
+	}
+	return s.leaseByToken(token)
+}
+
+// Converts an error from a checkpoint operation into a status for the client.
+func checkpointStatus(err error) error {
+	if errors.Is(err, lessor.ErrNoSuchLease) || errors.Is(err, lessor.ErrNoSuchCheckpoint) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+func TestCheckpointAndRollback(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+	cli := dialServer(server, t)
+	ctx := context.Background()
+
+	resp, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// You can't roll back to a checkpoint you haven't taken.
+	_, err = cli.Rollback(ctx, &pb.RollbackRequest{Token: resp.Token, Name: "setup"})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+
+	if _, err := cli.Checkpoint(ctx, &pb.CheckpointRequest{Token: resp.Token, Name: "setup"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Rollback(ctx, &pb.RollbackRequest{Token: resp.Token, Name: "setup"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid requests.
+	for _, tc := range []struct {
+		name string
+		req  *pb.CheckpointRequest
+		code codes.Code
+	}{
+		{"no token", &pb.CheckpointRequest{Name: "setup"}, codes.InvalidArgument},
+		{"no name", &pb.CheckpointRequest{Token: resp.Token}, codes.InvalidArgument},
+		{"unknown token", &pb.CheckpointRequest{Token: "bogus", Name: "setup"}, codes.NotFound},
+		// The lease ID is public, so it doesn't let anyone else roll back the lease.
+		{"lease ID", &pb.CheckpointRequest{Token: resp.LeaseId, Name: "setup"}, codes.NotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cli.Checkpoint(ctx, tc.req)
+			if got, want := status.Code(err), tc.code; got != want {
+				t.Fatalf("Got code %v, want %v", got, want)
+			}
+		})
+	}
+
+	// The lease is gone after it's released.
+	if _, err := cli.ReleaseLease(ctx, &pb.ReleaseLeaseRequest{Token: resp.Token}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.Checkpoint(ctx, &pb.CheckpointRequest{Token: resp.Token, Name: "setup"})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+}
+
+func TestCheckpointOnStream(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+	cli := dialServer(server, t)
+	ctx := context.Background()
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("initial message", t)
+	granted := c.GetResponse("lease available", t)
+	if granted.Token == "" {
+		t.Fatal("Got no token with the lease")
+	}
+	if _, err := cli.Checkpoint(ctx, &pb.CheckpointRequest{Token: granted.Token, Name: "setup"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Rollback(ctx, &pb.RollbackRequest{Token: granted.Token, Name: "setup"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token is forgotten when the lease ends.
+	if err := c.stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GetError("stream ended", t); err != io.EOF {
+		t.Fatalf("Got error %v, want EOF", err)
+	}
+	_, err := cli.Checkpoint(ctx, &pb.CheckpointRequest{Token: granted.Token, Name: "setup"})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// How long to wait for the holders of revoked leases to return them, which
+// they do right away unless something is stuck.
+const revokeGrace = 10 * time.Second
+
+// Drain prepares the service to shut down. It stops granting leases, tells
+// the holders of the current leases that the server is shutting down, and
+// waits until they have returned them or the context has ended, in which
+// case it revokes the remaining leases.
+func (s *Service) Drain(ctx context.Context) {
+	s.drainOnce.Do(func() { close(s.drainCh) })
+	lessors := s.readyLessors()
+	for _, l := range lessors {
+		l.Drain()
+	}
+	for _, l := range lessors {
+		if err := l.WaitDrained(ctx); err == nil {
+			continue
+		}
+		for _, info := range l.Leases() {
+			glog.Warningf("Revoking lease %s on %q, which wasn't returned in time", info.ID, info.Databases)
+			l.Revoke(info.ID)
+		}
+		graceCtx, cancel := context.WithTimeout(context.Background(), revokeGrace)
+		if err := l.WaitDrained(graceCtx); err != nil {
+			glog.Errorf("Leases of pool %q were not returned after revocation: %s", l.Name(), err)
+		}
+		cancel()
+	}
+}
+
+// Whether the service has started draining.
+func (s *Service) draining() bool {
+	select {
+	case <-s.drainCh:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+func TestDrain(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	// One client holds the only database, and another is waiting for it.
+	holder := doGetDatabaseInstance(server.serviceAddr, t)
+	go holder.Run()
+	if err := holder.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	holder.GetResponse("after first message", t)
+	holder.GetResponse("lease available", t)
+	waiter := doGetDatabaseInstance(server.serviceAddr, t)
+	go waiter.Run()
+	if err := waiter.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	waiter.GetResponse("after first message", t)
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		server.service.Drain(context.Background())
+	}()
+
+	// The holder is asked to return the lease, and the waiter is turned away.
+	if resp := holder.GetResponse("shutting down", t); !resp.ShuttingDown {
+		t.Fatalf("Got response %v, want shutting down", resp)
+	}
+	if err := waiter.GetError("draining", t); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Got error %v, want code %v", err, codes.Unavailable)
+	}
+	resp, err := server.service.GetStatus(context.Background(), &pb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.State, pb.GetStatusResponse_DRAINING; got != want {
+		t.Fatalf("Got state %v, want %v", got, want)
+	}
+
+	// The drain is done once the holder returns the lease.
+	select {
+	case <-drained:
+		t.Fatal("Drain() finished while the lease is held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if err := holder.stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	holder.AssertError("closed connection", io.EOF, t)
+	select {
+	case <-drained:
+	case <-time.After(expMessageDur):
+		t.Fatal("Drain() did not finish")
+	}
+
+	// New clients are turned away too.
+	late := doGetDatabaseInstance(server.serviceAddr, t)
+	go late.Run()
+	if err := late.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := late.GetError("draining", t); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Got error %v, want code %v", err, codes.Unavailable)
+	}
+}
+
+func TestDrainRevokes(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	c.GetResponse("lease available", t)
+
+	// The client doesn't return the lease in time, so it's revoked.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	server.service.Drain(ctx)
+	if resp := c.GetResponse("shutting down", t); !resp.ShuttingDown {
+		t.Fatalf("Got response %v, want shutting down", resp)
+	}
+	if err := c.GetError("revoked", t); status.Code(err) != codes.Aborted {
+		t.Fatalf("Got error %v, want code %v", err, codes.Aborted)
+	}
+	if got := server.lessor.Leases(); len(got) != 0 {
+		t.Fatalf("Got leases %v, want none", got)
+	}
+}
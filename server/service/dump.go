@@ -0,0 +1,56 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/karagog/db-provider/server/lessor"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// This file implements dumps of leased databases.
+
+// The size of the chunks in which we stream dumps to the client.
+const dumpChunkSize = 64 << 10
+
+func (s *Service) DumpDatabase(req *pb.DumpDatabaseRequest, srv pb.IntegrationTest_DumpDatabaseServer) error {
+	w := bufio.NewWriterSize(dumpSender{srv}, dumpChunkSize)
+	if err := s.Dump(srv.Context(), req, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Dump writes the dump that the request asks for. This lets the HTTP gateway
+// serve the dump as is.
+func (s *Service) Dump(ctx context.Context, req *pb.DumpDatabaseRequest, w io.Writer) error {
+	les, lease, err := s.leaseByToken(req.Token)
+	if err != nil {
+		return err
+	}
+	err = les.Dump(ctx, lease, req.Database, w)
+	switch {
+	case errors.Is(err, lessor.ErrNoSuchLease):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, lessor.ErrNotLeased):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+// Sends each write to the client as a chunk of the dump.
+type dumpSender struct {
+	srv pb.IntegrationTest_DumpDatabaseServer
+}
+
+func (d dumpSender) Write(p []byte) (int, error) {
+	if err := d.srv.Send(&pb.DumpDatabaseResponse{Data: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
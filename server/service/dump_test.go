@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/karagog/db-provider/server/lessor"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+func TestDumpDatabase(t *testing.T) {
+	// The dump is big enough to be split into chunks.
+	p := &fake.DatabaseProvider{Dump: strings.Repeat("INSERT INTO foo VALUES (1);\n", 5000)}
+	l := lessor.New(p, lessor.Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+	svc := New(simulated.NewClock(time.Now()), Options{})
+	svc.SetLessors(l)
+
+	ls, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterIntegrationTestServer(s, svc)
+	go s.Serve(ls)
+	defer s.Stop()
+	conn, err := grpc.Dial(ls.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cli := pb.NewIntegrationTestClient(conn)
+
+	acquired, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := func(req *pb.DumpDatabaseRequest) ([]byte, int, error) {
+		stream, err := cli.DumpDatabase(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		chunks := 0
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				return b.Bytes(), chunks, nil
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			b.Write(resp.Data)
+			chunks++
+		}
+	}
+
+	got, chunks, err := dump(&pb.DumpDatabaseRequest{Token: acquired.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != p.Dump {
+		t.Fatalf("Got a dump of %d bytes, want %d", len(got), len(p.Dump))
+	}
+	if chunks < 2 {
+		t.Fatalf("Got %d chunks, want several", chunks)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  *pb.DumpDatabaseRequest
+		code codes.Code
+	}{
+		{"no token", &pb.DumpDatabaseRequest{}, codes.InvalidArgument},
+		{"unknown token", &pb.DumpDatabaseRequest{Token: "bogus"}, codes.NotFound},
+		// The lease ID is public, so it doesn't let anyone else dump the lease.
+		{"lease ID", &pb.DumpDatabaseRequest{Token: acquired.LeaseId}, codes.NotFound},
+		{"not leased", &pb.DumpDatabaseRequest{Token: acquired.Token, Database: "bogus"}, codes.InvalidArgument},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := dump(tc.req)
+			if got, want := status.Code(err), tc.code; got != want {
+				t.Fatalf("Got code %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Package gateway serves the provider service over HTTP with JSON, for
+// clients that don't have gRPC, such as shell scripts.
+//
+// The messages are the JSON encoding of the protocol buffers, and the
+// endpoints are described by the OpenAPI document at /v1/openapi.json.
+package gateway
+
+import (
+	_ "embed"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/karagog/db-provider/server/proto"
+	"github.com/karagog/db-provider/server/service"
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// Register adds the endpoints to the mux, which serve requests with the service.
+func Register(mux *http.ServeMux, s *service.Service) {
+	mux.HandleFunc("/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	mux.HandleFunc("/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		req := &pb.GetStatusRequest{}
+		if v := r.URL.Query().Get("include_leases"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "invalid include_leases: %v", err))
+				return
+			}
+			req.IncludeLeases = b
+		}
+		resp, err := s.GetStatus(r.Context(), req)
+		writeResponse(w, resp, err)
+	})
+	mux.HandleFunc("/v1/leases/acquire", func(w http.ResponseWriter, r *http.Request) {
+		req := &pb.AcquireLeaseRequest{}
+		if readRequest(w, r, req) {
+			resp, err := s.AcquireLease(r.Context(), req)
+			writeResponse(w, resp, err)
+		}
+	})
+	mux.HandleFunc("/v1/leases/renew", func(w http.ResponseWriter, r *http.Request) {
+		req := &pb.RenewLeaseRequest{}
+		if readRequest(w, r, req) {
+			resp, err := s.RenewLease(r.Context(), req)
+			writeResponse(w, resp, err)
+		}
+	})
+	mux.HandleFunc("/v1/leases/release", func(w http.ResponseWriter, r *http.Request) {
+		req := &pb.ReleaseLeaseRequest{}
+		if readRequest(w, r, req) {
+			resp, err := s.ReleaseLease(r.Context(), req)
+			writeResponse(w, resp, err)
+		}
+	})
+	mux.HandleFunc("/v1/leases/checkpoint", func(w http.ResponseWriter, r *http.Request) {
+		req := &pb.CheckpointRequest{}
+		if readRequest(w, r, req) {
+			resp, err := s.Checkpoint(r.Context(), req)
+			writeResponse(w, resp, err)
+		}
+	})
+	mux.HandleFunc("/v1/leases/rollback", func(w http.ResponseWriter, r *http.Request) {
+		req := &pb.RollbackRequest{}
+		if readRequest(w, r, req) {
+			resp, err := s.Rollback(r.Context(), req)
+			writeResponse(w, resp, err)
+		}
+	})
+	mux.HandleFunc("/v1/leases/dump", func(w http.ResponseWriter, r *http.Request) {
+		req := &pb.DumpDatabaseRequest{}
+		if !readRequest(w, r, req) {
+			return
+		}
+		dw := &dumpWriter{w: w}
+		if err := s.Dump(r.Context(), req, dw); err != nil {
+			if !dw.started {
+				writeError(w, err)
+				return
+			}
+			// Too late to change the status, so the client gets a truncated
+			// dump, which lacks the final comment that says it's complete.
+			glog.Errorf("Error dumping database %q: %s", req.Database, err)
+		}
+	})
+}
+
+// Writes a dump as the response body, once it starts.
+type dumpWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (d *dumpWriter) Write(p []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		d.w.Header().Set("Content-Type", "application/sql")
+	}
+	return d.w.Write(p)
+}
+
+// Checks that the request uses the method. If not, it writes an error and returns false.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeStatus(w, http.StatusMethodNotAllowed,
+		status.Newf(codes.InvalidArgument, "method %s not allowed, use %s", r.Method, method))
+	return false
+}
+
+// Parses the JSON body of a POST request into the message. If that fails, it
+// writes the error and returns false.
+func readRequest(w http.ResponseWriter, r *http.Request, m proto.Message) bool {
+	if !allowMethod(w, r, http.MethodPost) {
+		return false
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "error reading request: %v", err))
+		return false
+	}
+	if len(body) == 0 {
+		return true // all defaults
+	}
+	if err := protojson.Unmarshal(body, m); err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "invalid request: %v", err))
+		return false
+	}
+	return true
+}
+
+// Writes the response as JSON, or the error if there is one.
+func writeResponse(w http.ResponseWriter, m proto.Message, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "error encoding response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// Writes the error as a JSON-encoded google.rpc.Status, with the HTTP status
+// that corresponds to its code.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeStatus(w, httpStatus(st.Code()), st)
+}
+
+// Writes the status as JSON with the given HTTP status.
+func writeStatus(w http.ResponseWriter, httpStatus int, st *status.Status) {
+	b, err := protojson.Marshal(st.Proto())
+	if err != nil {
+		glog.Errorf("Error encoding status %v: %v", st, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(b)
+}
+
+// Returns the HTTP status that corresponds to the gRPC code.
+func httpStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,165 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/karagog/db-provider/server/lessor"
+	"github.com/karagog/db-provider/server/lessor/databaseprovider/fake"
+	pb "github.com/karagog/db-provider/server/proto"
+	"github.com/karagog/db-provider/server/service"
+)
+
+// What the fake provider writes when it dumps a database.
+const testDump = "CREATE TABLE foo (id INT);\n"
+
+// Starts the gateway in front of a service with a single fake database, and returns its URL.
+func startGateway(t *testing.T) (string, *lessor.Lessor) {
+	l := lessor.New(&fake.DatabaseProvider{Dump: testDump}, lessor.Config{Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go l.Run(ctx)
+	svc := service.New(simulated.NewClock(time.Now()), service.Options{})
+	svc.SetLessors(l)
+
+	mux := http.NewServeMux()
+	Register(mux, svc)
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s.URL, l
+}
+
+// Sends the request and parses the response into resp, and returns the HTTP status.
+func do(t *testing.T, method, url, body string, resp proto.Message) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.StatusCode == http.StatusOK && resp != nil {
+		if err := protojson.Unmarshal(b, resp); err != nil {
+			t.Fatalf("Error parsing %q: %v", b, err)
+		}
+	}
+	return r.StatusCode
+}
+
+func TestLeaseLifecycle(t *testing.T) {
+	url, l := startGateway(t)
+
+	acquired := &pb.AcquireLeaseResponse{}
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/acquire", `{"heartbeatTtl": "60s"}`, acquired), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := len(acquired.ConnectionInfos), 1; got != want {
+		t.Fatalf("Got %d connection infos, want %d", got, want)
+	}
+
+	status := &pb.GetStatusResponse{}
+	if got, want := do(t, http.MethodGet, url+"/v1/status?include_leases=true", "", status), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := status.Pools[0].Leases[0].Id, acquired.LeaseId; got != want {
+		t.Fatalf("Got lease %q, want %q", got, want)
+	}
+
+	checkpoint := `{"token": "` + acquired.Token + `", "name": "setup"}`
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/checkpoint", checkpoint, &pb.CheckpointResponse{}), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/rollback", checkpoint, &pb.RollbackResponse{}), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+
+	token := `{"token": "` + acquired.Token + `"}`
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/renew", token, &pb.RenewLeaseResponse{}), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/release", token, &pb.ReleaseLeaseResponse{}), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got := l.Stats().Leased; got != 0 {
+		t.Fatalf("Got %d leased, want 0", got)
+	}
+
+	// Errors map to HTTP statuses.
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/release", token, nil), http.StatusNotFound; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/renew", `{"bogus": 1}`, nil), http.StatusBadRequest; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := do(t, http.MethodGet, url+"/v1/leases/acquire", "", nil), http.StatusMethodNotAllowed; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+}
+
+func TestDump(t *testing.T) {
+	url, _ := startGateway(t)
+	acquired := &pb.AcquireLeaseResponse{}
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/acquire", "", acquired), http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+
+	r, err := http.Post(url+"/v1/leases/dump", "application/json", strings.NewReader(`{"token": "`+acquired.Token+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	if got, want := r.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+	if got, want := r.Header.Get("Content-Type"), "application/sql"; got != want {
+		t.Fatalf("Got content type %q, want %q", got, want)
+	}
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), testDump; got != want {
+		t.Fatalf("Got dump %q, want %q", got, want)
+	}
+
+	if got, want := do(t, http.MethodPost, url+"/v1/leases/dump", `{"token": "`+acquired.LeaseId+`"}`, nil), http.StatusNotFound; got != want {
+		t.Fatalf("Got status %d, want %d", got, want)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	url, _ := startGateway(t)
+	r, err := http.Get(url + "/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	var doc struct {
+		Paths map[string]interface{}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/v1/status", "/v1/leases/acquire", "/v1/leases/renew", "/v1/leases/release", "/v1/leases/checkpoint", "/v1/leases/rollback", "/v1/leases/dump"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("Path %s is not documented", path)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/karagog/db-provider/server/lessor"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// Returns the lease to the lessor. If the client asked to retain the
+// databases, they are moved aside first, and this reports where they went.
+func release(les *lessor.Lessor, lease lessor.Lease, retain bool) []*pb.RetainedDatabase {
+	if !retain {
+		les.Return(lease)
+		return nil
+	}
+	// The client may be gone already, but the databases should be kept anyway.
+	retained, err := les.ReturnAndRetain(context.Background(), lease)
+	if err != nil {
+		glog.Errorf("Error retaining databases: %s", err)
+	}
+	var ret []*pb.RetainedDatabase
+	for _, r := range retained {
+		ret = append(ret, &pb.RetainedDatabase{
+			ConnectionInfo: r.ConnectionInfo,
+			ExpireTime:     timestamppb.New(r.ExpireTime),
+		})
+	}
+	return ret
+}
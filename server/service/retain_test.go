@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+func TestRetainOnRelease(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("initial message", t)
+	c.GetResponse("lease available", t)
+
+	// The client decides to retain the databases, e.g. because the test failed.
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{RetainOnRelease: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	resp := c.GetResponse("databases retained", t)
+	if got, want := len(resp.Retained), 1; got != want {
+		t.Fatalf("Got %d retained databases, want %d", got, want)
+	}
+	if resp.Retained[0].ConnectionInfo == nil {
+		t.Error("Got no connection info for the retained database")
+	}
+	if got, want := resp.Retained[0].ExpireTime.AsTime(), server.clock.Now().Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Got expire time %v, want %v", got, want)
+	}
+	if err := c.GetError("stream ended", t); err != io.EOF {
+		t.Fatalf("Got error %v, want EOF", err)
+	}
+
+	st, err := server.service.GetStatus(context.Background(), &pb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := st.Pools[0].Retained, int32(1); got != want {
+		t.Fatalf("Got %d retained, want %d", got, want)
+	}
+}
+
+func TestReleaseLeaseAndRetain(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+	cli := dialServer(server, t)
+	ctx := context.Background()
+
+	acquired, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cli.ReleaseLease(ctx, &pb.ReleaseLeaseRequest{Token: acquired.Token, Retain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(resp.Retained), 1; got != want {
+		t.Fatalf("Got %d retained databases, want %d", got, want)
+	}
+
+	// Databases aren't retained unless you ask.
+	acquired, err = cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = cli.ReleaseLease(ctx, &pb.ReleaseLeaseRequest{Token: acquired.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Retained) != 0 {
+		t.Fatalf("Got retained databases %v, want none", resp.Retained)
+	}
+}
@@ -33,6 +33,7 @@ func New(s *service.Service, address string) (*Runner, error) {
 		done:       make(chan bool),
 	}
 	pb.RegisterIntegrationTestServer(r.grpcServer, r.service)
+	pb.RegisterAdminServer(r.grpcServer, r.service)
 	return r, nil
 }
 
@@ -62,3 +63,14 @@ func (s *Runner) Stop() {
 	<-s.done
 	s.done = nil
 }
+
+// Stops the service once the RPCs in progress have finished. Drain the service
+// first, so that no stream is left waiting for or holding a lease.
+func (s *Runner) GracefulStop() {
+	if s.done == nil {
+		return // already stopped
+	}
+	s.grpcServer.GracefulStop()
+	<-s.done
+	s.done = nil
+}
@@ -16,9 +16,9 @@ import (
 
 func TestRunner(t *testing.T) {
 	// Initialize a fake service for testing.
-	svc := service.New(simulated.NewClock(time.Now()))
-	l := lessor.New(&fake.DatabaseProvider{}, 1)
-	svc.SetLessor(l)
+	svc := service.New(simulated.NewClock(time.Now()), service.Options{})
+	l := lessor.New(&fake.DatabaseProvider{}, lessor.Config{Size: 1})
+	svc.SetLessors(l)
 	ctx := context.Background()
 	go l.Run(ctx)
 
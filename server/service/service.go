@@ -2,45 +2,166 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/karagog/clock-go"
 	"github.com/karagog/db-provider/server/lessor"
 	pb "github.com/karagog/db-provider/server/proto"
 )
 
+// How long before a lease expires that we start warning the client, unless configured otherwise.
+const defaultExpiryWarning = time.Minute
+
+// Options configure the service.
+type Options struct {
+	// MaxLeaseDuration caps how long a client may hold a lease. When a lease
+	// expires the stream is ended and the database goes back to the pool.
+	// Zero means leases are unlimited.
+	MaxLeaseDuration time.Duration
+
+	// DefaultLeaseDuration applies to requests that don't ask for a specific
+	// duration. Zero means the maximum.
+	DefaultLeaseDuration time.Duration
+
+	// ExpiryWarning is how long before a lease expires that we start sending
+	// warnings to the client. Zero means a minute.
+	ExpiryWarning time.Duration
+
+	// MaxWait caps how long a client may wait for a lease before the request
+	// fails with RESOURCE_EXHAUSTED. Zero means clients may wait forever.
+	MaxWait time.Duration
+
+	// HeartbeatTTL is how long a lease from AcquireLease lasts unless the
+	// client renews it, if the client doesn't ask for something else.
+	// Zero means a minute.
+	HeartbeatTTL time.Duration
+}
+
 type Service struct {
 	pb.UnimplementedIntegrationTestServer
+	pb.UnimplementedAdminServer
+
+	clock     clock.Clock
+	opts      Options // const
+	initDone  chan bool
+	lessors   map[string]*lessor.Lessor // by pool name
+	drainCh   chan struct{}             // closed when the service starts draining
+	drainOnce sync.Once
 
-	clock    clock.Clock
-	initDone chan bool
-	lessor   *lessor.Lessor
+	mu      sync.Mutex             // guards the members below
+	tokens  map[string]*tokenLease // by token
+	streams map[string]streamLease // leases held on a GetDatabaseInstance stream, by token
 }
 
-func New(clock clock.Clock) *Service {
+func New(clock clock.Clock, opts Options) *Service {
+	if opts.ExpiryWarning == 0 {
+		opts.ExpiryWarning = defaultExpiryWarning
+	}
+	if opts.HeartbeatTTL == 0 {
+		opts.HeartbeatTTL = defaultHeartbeatTTL
+	}
 	return &Service{
 		clock:    clock,
+		opts:     opts,
 		initDone: make(chan bool),
+		drainCh:  make(chan struct{}),
+		tokens:   make(map[string]*tokenLease),
+		streams:  make(map[string]streamLease),
 	}
 }
 
-func (s *Service) GetStatus(ctx context.Context, _ *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
-	return &pb.GetStatusResponse{State: pb.GetStatusResponse_UP}, nil
+func (s *Service) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
+	resp := &pb.GetStatusResponse{State: pb.GetStatusResponse_STARTING}
+	lessors := s.readyLessors()
+	if lessors == nil {
+		return resp, nil // no pools yet
+	}
+	starting, degraded := false, false
+	for _, l := range lessors {
+		st := l.Stats()
+		starting = starting || st.Starting
+		degraded = degraded || st.Failed > 0 || st.LowCapacity || st.Unavailable > 0
+		pool := &pb.PoolStatus{
+			Name:        l.Name(),
+			Size:        int32(st.Total),
+			Ready:       int32(st.Ready),
+			Leased:      int32(st.Leased),
+			Resetting:   int32(st.Resetting),
+			Failed:      int32(st.Failed),
+			Waiting:     int32(st.Waiting),
+			Retained:    int32(st.Retained),
+			Retrying:    int32(st.Retrying),
+			LowCapacity: st.LowCapacity,
+			MinSize:     int32(l.Size()),
+			MaxSize:     int32(l.MaxSize()),
+			Reclaimed:   int32(st.Reclaimed),
+			Unavailable: int32(st.Unavailable),
+			Recovered:   int32(st.Recovered),
+		}
+		for _, q := range l.Quarantined() {
+			pool.Quarantined = append(pool.Quarantined, &pb.QuarantinedDatabase{
+				Name:           q.Name,
+				Attempts:       int32(q.Attempts),
+				Error:          q.Error,
+				QuarantineTime: timestamppb.New(q.Time),
+			})
+		}
+		for _, e := range l.ScaleEvents() {
+			pool.ScaleEvents = append(pool.ScaleEvents, &pb.ScaleEvent{
+				Time:     timestamppb.New(e.Time),
+				FromSize: int32(e.From),
+				ToSize:   int32(e.To),
+				Reason:   e.Reason,
+			})
+		}
+		if req.IncludeLeases {
+			pool.Leases = leaseInfos(l)
+		}
+		resp.Pools = append(resp.Pools, pool)
+	}
+	sort.Slice(resp.Pools, func(i, j int) bool { return resp.Pools[i].Name < resp.Pools[j].Name })
+	switch {
+	case s.draining():
+		resp.State = pb.GetStatusResponse_DRAINING
+	case starting:
+		resp.State = pb.GetStatusResponse_STARTING
+	case degraded:
+		resp.State = pb.GetStatusResponse_DEGRADED
+	default:
+		resp.State = pb.GetStatusResponse_UP
+	}
+	return resp, nil
 }
 
-// Sets the lessor sometime after creation, which allows the server to start providing databases.
+// Sets the lessors sometime after creation, which allows the server to start providing databases.
+// Each lessor manages a pool, which clients select by name.
 // Until that point, the status RPC will show that we're still starting up, and
 // requests for database instances will block indefinitely until it's available.
 // This can only be set once, setting it twice is a fatal error.
-func (s *Service) SetLessor(l *lessor.Lessor) {
-	if s.lessor != nil {
-		panic("lessor has already been set")
+func (s *Service) SetLessors(ls ...*lessor.Lessor) {
+	if s.lessors != nil {
+		panic("lessors have already been set")
 	}
-	s.lessor = l
+	lessors := make(map[string]*lessor.Lessor)
+	for _, l := range ls {
+		if _, ok := lessors[l.Name()]; ok {
+			panic(fmt.Sprintf("duplicate pool name %q", l.Name()))
+		}
+		lessors[l.Name()] = l
+	}
+	s.lessors = lessors
 	close(s.initDone)
 }
 
@@ -48,18 +169,31 @@ func (s *Service) GetDatabaseInstance(srv pb.IntegrationTest_GetDatabaseInstance
 	glog.V(3).Infof("Handling GetDatabaseInstance request...")
 
 	// Get the first message from the stream, which initiates the request.
-	if _, err := srv.Recv(); err != nil {
+	req, err := srv.Recv()
+	if err != nil {
 		glog.Errorf("Error receiving first message in stream: %v", err)
 		return err
 	}
+	ttl, err := s.leaseDuration(req)
+	if err != nil {
+		return err
+	}
+	maxWait, err := s.maxWait(req)
+	if err != nil {
+		return err
+	}
 
-	// Wait here indefinitely until the provider is ready.
-	for s.lessor == nil {
-		select {
-		case <-srv.Context().Done():
-			return fmt.Errorf("client cancelled")
-		case <-s.initDone:
-		}
+	les, err := s.pool(srv.Context(), req)
+	if err != nil {
+		return err
+	}
+	pool := les.Name()
+	glog.V(1).Infof("Lease requested from pool %q by %s", pool, lessor.DescribeClient(req.ClientInfo))
+
+	// Set to 1 if the client asks to retain the databases when the lease ends.
+	var retain int32
+	if req.RetainOnRelease {
+		retain = 1
 	}
 
 	// Spawn a goroutine for consuming further messages (if any) from the client.
@@ -72,19 +206,33 @@ func (s *Service) GetDatabaseInstance(srv pb.IntegrationTest_GetDatabaseInstance
 				clientErrCh <- err
 				return
 			}
+			if req.RetainOnRelease {
+				glog.V(1).Infof("Client asked to retain the databases")
+				atomic.StoreInt32(&retain, 1)
+				continue
+			}
 			// Can safely ignore the spurious message, but it may indicate a client-side bug.
 			glog.Warningf("Received unexpected message from the client: %v", req)
 		}
 	}()
 
+	// This fires if we give up waiting for the lease.
+	var waitCh <-chan time.Time
+	if maxWait > 0 {
+		waitTmr := s.clock.NewTimer(maxWait)
+		defer waitTmr.Stop()
+		waitCh = waitTmr.C()
+	}
+
 	// Spawn another goroutine to ask the manager for an instance (as this can block indefinitely).
 	var lease lessor.Lease
 	var leaseErr error
 	leaseCh := make(chan bool)
 	ctx, cancel := context.WithCancel(srv.Context())
+	leaseReq := les.Request(leaseOptions(srv.Context(), req))
 	go func(ctx context.Context) {
 		defer func() { leaseCh <- true }()
-		gotHandle, err := s.lessor.Lease(ctx)
+		gotHandle, err := leaseReq.Wait(ctx)
 		if err != nil {
 			leaseErr = err
 			return
@@ -102,13 +250,31 @@ func (s *Service) GetDatabaseInstance(srv pb.IntegrationTest_GetDatabaseInstance
 		}
 	}
 
+	// The secret with which the client takes checkpoints, once the lease is granted.
+	var token string
+
+	// Returns the lease, and tells the client where the databases went if it
+	// asked to retain them.
+	returnLease := func() {
+		if token != "" {
+			s.dropStream(token)
+		}
+		retained := release(les, lease, atomic.LoadInt32(&retain) == 1)
+		if len(retained) > 0 {
+			srv.Send(&pb.GetDatabaseInstanceResponse{
+				Status:   "databases retained for debugging",
+				Retained: retained,
+			})
+		}
+	}
+
 	// Sends a response to the client. Handles errors by resetting the instance.
 	sendResp := func(resp *pb.GetDatabaseInstanceResponse) error {
 		if err := srv.Send(resp); err != nil {
 			// Client disconnected?
 			cancelAndJoinLeaseRequest()
 			if lease != nil {
-				s.lessor.Return(lease)
+				returnLease()
 			}
 			return err
 		}
@@ -117,30 +283,114 @@ func (s *Service) GetDatabaseInstance(srv pb.IntegrationTest_GetDatabaseInstance
 	if err := sendResp(&pb.GetDatabaseInstanceResponse{Status: "requesting lease"}); err != nil {
 		return err
 	}
-	status := "waiting for lease"
 	period := 10 * time.Second
 	tmr := s.clock.NewTimer(period)
+	defer tmr.Stop()
+
+	// Once the lease is granted, this timer fires first when it's time to start
+	// warning the client, and then again when the lease expires.
+	var expireTime time.Time
+	var expiryTmr clock.Timer
+	var expiryCh <-chan time.Time
+	warning := false
+
+	// This is closed if an administrator revokes the lease.
+	var revokedCh <-chan struct{}
+
+	// This is closed when the server starts shutting down. A request that is
+	// still waiting fails then, but holders are asked to return their leases.
+	drainCh := les.Draining()
+	expiryWarning := func() *pb.GetDatabaseInstanceResponse {
+		return &pb.GetDatabaseInstanceResponse{
+			Status: fmt.Sprintf("lease expires in %v",
+				expireTime.Sub(s.clock.Now()).Round(time.Second)),
+			ExpireTime:    timestamppb.New(expireTime),
+			ExpiryWarning: true,
+		}
+	}
 	for {
 		select {
 		case <-tmr.C():
 			// Send periodic status messages.
-			if err := sendResp(&pb.GetDatabaseInstanceResponse{Status: status}); err != nil {
+			var resp *pb.GetDatabaseInstanceResponse
+			switch {
+			case warning:
+				resp = expiryWarning()
+			case !leaseGranted:
+				resp = waitingStatus(leaseReq)
+			default:
+				resp = &pb.GetDatabaseInstanceResponse{Status: "lease active"}
+			}
+			if err := sendResp(resp); err != nil {
 				return err
 			}
 			tmr.Reset(period)
 		case <-leaseCh:
 			if leaseErr != nil {
-				return leaseErr
+				return leaseStatus(leaseErr)
 			}
 			leaseGranted = true
-			status = "lease active"
+			waitCh = nil
+			token = newToken()
+			s.mu.Lock()
+			s.streams[token] = streamLease{les, lease}
+			s.mu.Unlock()
 			// Notify the client that the lease is active.
 			resp := &pb.GetDatabaseInstanceResponse{
-				ConnectionInfo: s.lessor.ConnectionInfo(lease),
+				ConnectionInfo:  les.ConnectionInfo(lease),
+				ConnectionInfos: les.ConnectionInfos(lease),
+				LeaseId:         les.Info(lease).ID,
+				Token:           token,
+			}
+			revokedCh = les.Revoked(lease)
+			if ttl > 0 {
+				expireTime = s.clock.Now().Add(ttl)
+				resp.ExpireTime = timestamppb.New(expireTime)
+				warnBefore := s.opts.ExpiryWarning
+				if warnBefore > ttl/2 {
+					warnBefore = ttl / 2
+				}
+				expiryTmr = s.clock.NewTimer(ttl - warnBefore)
+				defer expiryTmr.Stop()
+				expiryCh = expiryTmr.C()
 			}
 			if err := sendResp(resp); err != nil {
 				return err
 			}
+		case <-expiryCh:
+			if !warning {
+				warning = true
+				if err := sendResp(expiryWarning()); err != nil {
+					return err
+				}
+				expiryTmr.Reset(expireTime.Sub(s.clock.Now()))
+				break
+			}
+			glog.Warningf("Lease expired after %v, reclaiming it", ttl)
+			returnLease()
+			return status.Errorf(codes.DeadlineExceeded, "lease expired after %v", ttl)
+		case <-waitCh:
+			st := les.Stats()
+			cancelAndJoinLeaseRequest()
+			if lease != nil {
+				les.Return(lease) // granted just as we gave up
+			}
+			return waitExhausted(pool, maxWait, st)
+		case <-drainCh:
+			drainCh = nil
+			if !leaseGranted {
+				break
+			}
+			if err := sendResp(&pb.GetDatabaseInstanceResponse{
+				Status:       "server shutting down, please return the lease",
+				ShuttingDown: true,
+			}); err != nil {
+				return err
+			}
+		case <-revokedCh:
+			glog.Warningf("Lease %s was revoked, reclaiming it", les.Info(lease).ID)
+			returnLease()
+			return status.Error(codes.Aborted, "lease revoked by an administrator")
 		case err := <-clientErrCh:
 			// Client is done with the lease (either they said they're done or they crashed).
 			glog.V(3).Infof("Client is done: %v", err)
@@ -151,17 +401,136 @@ func (s *Service) GetDatabaseInstance(srv pb.IntegrationTest_GetDatabaseInstance
 				glog.V(2).Infof("Recieved client error: %v", err)
 			}
 			if lease != nil {
-				s.lessor.Return(lease)
+				returnLease()
 			}
 			return err
 		case <-srv.Context().Done():
 			glog.V(3).Infof("Client's request context is done")
 			cancelAndJoinLeaseRequest()
 			if lease != nil {
-				s.lessor.Return(lease)
+				returnLease()
 			}
 			return nil
 		}
 
 	}
 }
+
+// Tells the client where they are in the queue for a lease.
+func waitingStatus(r *lessor.Request) *pb.GetDatabaseInstanceResponse {
+	pos := r.Position()
+	if pos == 0 {
+		// Not queued yet, for example because the schema template is being built.
+		return &pb.GetDatabaseInstanceResponse{Status: "waiting for lease"}
+	}
+	resp := &pb.GetDatabaseInstanceResponse{
+		Status:        fmt.Sprintf("waiting for lease (position %d in queue)", pos),
+		QueuePosition: int32(pos),
+	}
+	if wait := r.EstimatedWait(); wait > 0 {
+		resp.Status = fmt.Sprintf("waiting for lease (position %d in queue, estimated wait %v)",
+			pos, wait.Round(time.Second))
+		resp.EstimatedWait = durationpb.New(wait)
+	}
+	return resp
+}
+
+// Waits indefinitely until the provider is ready, and returns the pool from
+// which the client wants a lease.
+func (s *Service) pool(ctx context.Context, req *pb.GetDatabaseInstanceRequest) (*lessor.Lessor, error) {
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("client cancelled")
+	case <-s.drainCh:
+		return nil, status.Error(codes.Unavailable, "server shutting down")
+	case <-s.initDone:
+	}
+	if s.draining() {
+		return nil, status.Error(codes.Unavailable, "server shutting down")
+	}
+	pool := req.Pool
+	if pool == "" {
+		pool = lessor.DefaultPool
+	}
+	les, ok := s.lessors[pool]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no such pool: %q", pool)
+	}
+	return les, nil
+}
+
+// Returns the error for a client who gave up waiting for a lease.
+func waitExhausted(pool string, maxWait time.Duration, st lessor.Stats) error {
+	glog.Warningf("Gave up waiting for a lease from pool %q after %v", pool, maxWait)
+	return status.Errorf(codes.ResourceExhausted,
+		"no database available in pool %q after waiting %v (pool size %d, %d clients waiting)",
+		pool, maxWait, st.Total, st.Waiting)
+}
+
+// Returns how long the client may hold the lease they requested, or zero if
+// there is no limit.
+func (s *Service) leaseDuration(req *pb.GetDatabaseInstanceRequest) (time.Duration, error) {
+	var ttl time.Duration
+	if req.LeaseDuration != nil {
+		if err := req.LeaseDuration.CheckValid(); err != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "invalid lease duration: %v", err)
+		}
+		if ttl = req.LeaseDuration.AsDuration(); ttl <= 0 {
+			return 0, status.Errorf(codes.InvalidArgument, "lease duration must be positive, got %v", ttl)
+		}
+	}
+	if ttl == 0 {
+		ttl = s.opts.DefaultLeaseDuration
+	}
+	if max := s.opts.MaxLeaseDuration; max > 0 && (ttl == 0 || ttl > max) {
+		ttl = max
+	}
+	return ttl, nil
+}
+
+// Returns how long the client may wait for a lease, or zero if there is no limit.
+func (s *Service) maxWait(req *pb.GetDatabaseInstanceRequest) (time.Duration, error) {
+	var wait time.Duration
+	if req.MaxWait != nil {
+		if err := req.MaxWait.CheckValid(); err != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "invalid max wait: %v", err)
+		}
+		if wait = req.MaxWait.AsDuration(); wait <= 0 {
+			return 0, status.Errorf(codes.InvalidArgument, "max wait must be positive, got %v", wait)
+		}
+	}
+	if max := s.opts.MaxWait; max > 0 && (wait == 0 || wait > max) {
+		wait = max
+	}
+	return wait, nil
+}
+
+// Returns the lessor options that fulfill the request.
+func leaseOptions(ctx context.Context, req *pb.GetDatabaseInstanceRequest) lessor.LeaseOptions {
+	opts := lessor.LeaseOptions{
+		Metadata: clientMetadata(ctx),
+		Client:   req.ClientInfo,
+		Count:    int(req.Count),
+	}
+	if req.Schema != nil {
+		opts.Schema = &lessor.Schema{
+			Hash: req.Schema.Hash,
+			SQL:  req.Schema.Sql,
+		}
+	}
+	return opts
+}
+
+// Converts an error from the lessor into a status for the client.
+func leaseStatus(err error) error {
+	var schemaErr *lessor.SchemaError
+	switch {
+	case errors.Is(err, lessor.ErrUnknownSchema):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &schemaErr), errors.Is(err, lessor.ErrBadCount):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, lessor.ErrDraining):
+		return status.Error(codes.Unavailable, "server shutting down")
+	}
+	return err
+}
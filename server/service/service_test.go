@@ -2,13 +2,20 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-test/deep"
 	"github.com/golang/glog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/karagog/clock-go/simulated"
 	"github.com/karagog/db-provider/server/lessor"
@@ -36,6 +43,11 @@ type serverCtl struct {
 //
 // Call `stop` to kill the server.
 func startServer(t *testing.T) (ctl *serverCtl, stop func()) {
+	return startServerWithOptions(t, Options{})
+}
+
+// Like startServer(), but configures the service with the given options.
+func startServerWithOptions(t *testing.T, opts Options) (ctl *serverCtl, stop func()) {
 	// Set up a local grpc instance of the test service.
 	ls, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
@@ -44,7 +56,7 @@ func startServer(t *testing.T) (ctl *serverCtl, stop func()) {
 
 	s := grpc.NewServer()
 	c := simulated.NewClock(time.Date(2021, 3, 26, 0, 0, 0, 0, time.UTC))
-	l := lessor.New(&fake.DatabaseProvider{}, 1) // only one instance available
+	l := lessor.New(&fake.DatabaseProvider{}, lessor.Config{Size: 1, Clock: c}) // only one instance available
 
 	// The lessor needs to run in a background context, which only ends
 	// when we cancel the context.
@@ -55,8 +67,9 @@ func startServer(t *testing.T) (ctl *serverCtl, stop func()) {
 		l.Run(lessorCtx)
 	}()
 
-	svc := New(c)
+	svc := New(c, opts)
 	pb.RegisterIntegrationTestServer(s, svc)
+	pb.RegisterAdminServer(s, svc)
 	shuttingDown := false
 	done := make(chan bool)
 	go func() {
@@ -104,28 +117,202 @@ func TestGetStatus(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// Check that the status is reported as "up" regardless if the database is present.
-	if got, want := resp.State, pb.GetStatusResponse_UP; got != want {
+	// Check that the status is reported as "starting" until the lessor is set.
+	if got, want := resp.State, pb.GetStatusResponse_STARTING; got != want {
 		t.Fatalf("Got %v, want %v", got, want)
 	}
 
 	// Assign the lessor, which is the signal that tells it to start providing databases.
-	server.service.SetLessor(server.lessor)
+	server.service.SetLessors(server.lessor)
+
+	// It's up as soon as the pool is warm.
+	resp = waitForState(server.service, pb.GetStatusResponse_UP, t)
+	if diff := deep.Equal(resp.Pools, []*pb.PoolStatus{{Name: lessor.DefaultPool, Size: 1, MinSize: 1, MaxSize: 1, Ready: 1}}); diff != nil {
+		t.Fatal(diff)
+	}
 
-	resp, err = cli.GetStatus(ctx, &pb.GetStatusRequest{})
+	// The counters reflect the leases and the clients waiting for them.
+	l, err := server.lessor.Lease(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go server.lessor.Lease(waitCtx)
+	for resp.Pools[0].Waiting == 0 {
+		if resp, err = cli.GetStatus(ctx, &pb.GetStatusRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := deep.Equal(resp.Pools, []*pb.PoolStatus{{Name: lessor.DefaultPool, Size: 1, MinSize: 1, MaxSize: 1, Leased: 1, Waiting: 1}}); diff != nil {
+		t.Fatal(diff)
+	}
+	cancel()
+	server.lessor.Return(l)
+}
 
-	if got, want := resp.State, pb.GetStatusResponse_UP; got != want {
-		t.Fatalf("Got %v, want %v", got, want)
+func TestGetStatusDegraded(t *testing.T) {
+	c := simulated.NewClock(time.Now())
+	l := lessor.New(&fake.DatabaseProvider{CreateErr: fmt.Errorf("Oof!")}, lessor.Config{Size: 1, Clock: c})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+	svc := New(c, Options{})
+	svc.SetLessors(l)
+
+	// The database is waiting to retry, so the pool is short of capacity.
+	resp := waitForState(svc, pb.GetStatusResponse_DEGRADED, t)
+	if diff := deep.Equal(resp.Pools, []*pb.PoolStatus{{Name: lessor.DefaultPool, Size: 1, MinSize: 1, MaxSize: 1, Retrying: 1, LowCapacity: true}}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestGetStatusQuarantined(t *testing.T) {
+	c := simulated.NewClock(time.Now())
+	l := lessor.New(&fake.DatabaseProvider{CreateErr: fmt.Errorf("Oof!")},
+		lessor.Config{Size: 1, Clock: c, ResetAttempts: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+	svc := New(c, Options{})
+	svc.SetLessors(l)
+
+	// The replacement waits for the clock, which doesn't move.
+	resp := waitForState(svc, pb.GetStatusResponse_DEGRADED, t)
+	if diff := deep.Equal(resp.Pools, []*pb.PoolStatus{{
+		Name:      lessor.DefaultPool,
+		Size:      1,
+		MinSize:   1,
+		MaxSize:   1,
+		Resetting: 1,
+		Failed:    1,
+		Quarantined: []*pb.QuarantinedDatabase{{
+			Name:           "testserver_db_0",
+			Attempts:       1,
+			Error:          "Oof!",
+			QuarantineTime: timestamppb.New(c.Now()),
+		}},
+	}}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+// Polls the status until the service reaches the state, and returns the status.
+func waitForState(svc *Service, state pb.GetStatusResponse_State, t *testing.T) *pb.GetStatusResponse {
+	deadline := time.Now().Add(expMessageDur)
+	for {
+		resp, err := svc.GetStatus(context.Background(), &pb.GetStatusRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.State == state {
+			return resp
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Got state %v, want %v", resp.State, state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGetDatabaseInstanceFromNamedPool(t *testing.T) {
+	server, stop := startServer(t)
+	defer stop()
+
+	// Add a second pool, whose databases we can tell apart by their connection info.
+	p := &fake.DatabaseProvider{
+		Info: pb.ConnectionInfo{RootConn: &pb.ConnectionDetails{Database: "legacy"}},
+	}
+	legacy := lessor.New(p, lessor.Config{Name: "legacy", Size: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go legacy.Run(ctx)
+	server.service.SetLessors(server.lessor, legacy)
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{Pool: "legacy"}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	resp := c.GetResponse("lease available", t)
+	if got, want := resp.ConnectionInfo.GetRootConn().GetDatabase(), "legacy"; got != want {
+		t.Fatalf("Got database %q, want %q", got, want)
+	}
+
+	// Both pools are listed in the status.
+	conn, err := grpc.Dial(server.serviceAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	statusResp, err := pb.NewIntegrationTestClient(conn).GetStatus(ctx, &pb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(statusResp.Pools, []*pb.PoolStatus{
+		{Name: lessor.DefaultPool, Size: 1, MinSize: 1, MaxSize: 1, Ready: 1},
+		{Name: "legacy", Size: 1, MinSize: 1, MaxSize: 1, Leased: 1},
+	}); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestGetDatabaseInstanceFromUnknownPool(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{Pool: "nope"}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.GetError("unknown pool", t)
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+}
+
+func TestGetMultipleDatabaseInstances(t *testing.T) {
+	server, stop := startServer(t)
+	defer stop()
+	multi := lessor.New(&fake.DatabaseProvider{}, lessor.Config{Name: "multi", Size: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go multi.Run(ctx)
+	server.service.SetLessors(server.lessor, multi)
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{Pool: "multi", Count: 3}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	resp := c.GetResponse("lease available", t)
+	if got, want := len(resp.ConnectionInfos), 3; got != want {
+		t.Fatalf("Got %d connection infos, want %d", got, want)
+	}
+	if got, want := multi.Stats().Leased, 3; got != want {
+		t.Fatalf("Got %d leased, want %d", got, want)
+	}
+
+	// We can't ask for more than the pool has.
+	c = doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{Pool: "multi", Count: 4}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	err := c.GetError("too many databases", t)
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Fatalf("Got code %v (%v), want %v", got, err, want)
 	}
 }
 
 // Test a nominal client-server interaction.
 func TestGetDatabaseInstance(t *testing.T) {
 	server, stop := startServer(t)
-	server.service.SetLessor(server.lessor)
+	server.service.SetLessors(server.lessor)
 	defer stop()
 
 	// There is only one lease available, so let's grab it now to cause
@@ -149,12 +336,19 @@ func TestGetDatabaseInstance(t *testing.T) {
 	// Expect immediate acknowledgement of the request.
 	c.GetResponse("after first message", t)
 
-	// After some time passes waiting for the lease, the server sends a status update.
+	// After some time passes waiting for the lease, the server sends a status
+	// update that tells us where we are in the queue.
+	for server.lessor.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
 	server.clock.Advance(time.Minute)
 	resp := c.GetResponse("periodic update", t)
 	if resp.Status == "" {
 		t.Error("Got empty status, want a status update")
 	}
+	if got, want := resp.QueuePosition, int32(1); got != want {
+		t.Errorf("Got queue position %d, want %d", got, want)
+	}
 
 	// A lease becomes available. Expect an immediate update.
 	server.lessor.Return(lease)
@@ -212,7 +406,7 @@ func TestServerNotReady(t *testing.T) {
 
 func TestServerDisconnect(t *testing.T) {
 	server, stop := startServer(t)
-	server.service.SetLessor(server.lessor)
+	server.service.SetLessors(server.lessor)
 	defer stop()
 
 	c := doGetDatabaseInstance(server.serviceAddr, t)
@@ -231,7 +425,7 @@ func TestServerDisconnect(t *testing.T) {
 
 func TestClientGivesUpWithoutLease(t *testing.T) {
 	server, stop := startServer(t)
-	server.service.SetLessor(server.lessor)
+	server.service.SetLessors(server.lessor)
 	defer stop()
 
 	// Grab and hold the only lease so the client can't get it.
@@ -255,7 +449,7 @@ func TestClientGivesUpWithoutLease(t *testing.T) {
 
 func TestClientClosesConnectionBeforeFirstMessage(t *testing.T) {
 	server, stop := startServer(t)
-	server.service.SetLessor(server.lessor)
+	server.service.SetLessors(server.lessor)
 	defer stop()
 
 	c := doGetDatabaseInstance(server.serviceAddr, t)
@@ -269,6 +463,246 @@ func TestClientClosesConnectionBeforeFirstMessage(t *testing.T) {
 	c.AssertError("premature broken connection", nil, t)
 }
 
+func TestGetDatabaseInstanceWithSchema(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	// Asking for a schema by hash fails until it's been built.
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{
+		Schema: &pb.Schema{Hash: "v1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	err := c.GetError("unknown schema", t)
+	if got, want := status.Code(err), codes.FailedPrecondition; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+
+	c = doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{
+		Schema: &pb.Schema{Hash: "v1", Sql: "CREATE TABLE foo (id INT)"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	if resp := c.GetResponse("lease available", t); resp.ConnectionInfo == nil {
+		t.Fatal("Got nil connection info, want info")
+	}
+}
+
+func TestLeaseExpires(t *testing.T) {
+	server, stop := startServerWithOptions(t, Options{
+		MaxLeaseDuration: 5 * time.Minute,
+		ExpiryWarning:    time.Minute,
+	})
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{
+		LeaseDuration: durationpb.New(time.Hour), // more than allowed
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	start := server.clock.Now()
+	resp := c.GetResponse("lease available", t)
+	if resp.ConnectionInfo == nil {
+		t.Fatal("Got nil connection info, want info")
+	}
+	// The lease is capped at the maximum duration.
+	if got, want := resp.ExpireTime.AsTime(), start.Add(5*time.Minute); !got.Equal(want) {
+		t.Fatalf("Got expire time %v, want %v", got, want)
+	}
+
+	// As the expiry approaches, we get a warning.
+	server.clock.Advance(4 * time.Minute)
+	for resp = c.GetResponse("expiry warning", t); !resp.ExpiryWarning; {
+		resp = c.GetResponse("expiry warning", t) // skip the periodic status
+	}
+
+	// When the lease expires, the stream ends with an error and the database
+	// goes back to the pool.
+	server.clock.Advance(time.Minute)
+	var err error
+	for err == nil {
+		select {
+		case <-c.respCh: // skip any further warnings
+		case err = <-c.errCh:
+		case <-time.After(expMessageDur):
+			t.Fatal("Got no error, want error")
+		}
+	}
+	if got, want := status.Code(err), codes.DeadlineExceeded; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	if _, err := server.lessor.Lease(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaseDuration(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		opts      Options
+		requested *durationpb.Duration
+		want      time.Duration
+		wantErr   bool
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:      "requested without a cap",
+			requested: durationpb.New(time.Hour),
+			want:      time.Hour,
+		},
+		{
+			name:      "capped",
+			opts:      Options{MaxLeaseDuration: time.Minute},
+			requested: durationpb.New(time.Hour),
+			want:      time.Minute,
+		},
+		{
+			name:      "shorter than the cap",
+			opts:      Options{MaxLeaseDuration: time.Hour},
+			requested: durationpb.New(time.Minute),
+			want:      time.Minute,
+		},
+		{
+			name: "default is the cap",
+			opts: Options{MaxLeaseDuration: time.Hour},
+			want: time.Hour,
+		},
+		{
+			name: "default",
+			opts: Options{MaxLeaseDuration: time.Hour, DefaultLeaseDuration: time.Minute},
+			want: time.Minute,
+		},
+		{
+			name:      "negative",
+			requested: durationpb.New(-time.Minute),
+			wantErr:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(simulated.NewClock(time.Now()), tc.opts)
+			got, err := s.leaseDuration(&pb.GetDatabaseInstanceRequest{LeaseDuration: tc.requested})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Got error %v, want error: %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("Got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxWaitExceeded(t *testing.T) {
+	server, stop := startServerWithOptions(t, Options{MaxWait: 5 * time.Minute})
+	server.service.SetLessors(server.lessor)
+	defer stop()
+
+	// Hold the only database, so the client has to wait.
+	lease, err := server.lessor.Lease(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.lessor.Return(lease)
+
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{
+		MaxWait: durationpb.New(2 * time.Minute), // less than the server's maximum
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	for server.lessor.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// We get the usual status updates until we've waited long enough, and
+	// then the stream ends with an error.
+	server.clock.Advance(time.Minute)
+	c.GetResponse("periodic update", t)
+	server.clock.Advance(time.Minute)
+	err = nil
+	for err == nil {
+		select {
+		case <-c.respCh: // skip any further updates
+		case err = <-c.errCh:
+		case <-time.After(expMessageDur):
+			t.Fatal("Got no error, want error")
+		}
+	}
+	if got, want := status.Code(err), codes.ResourceExhausted; got != want {
+		t.Fatalf("Got code %v (%v), want %v", got, err, want)
+	}
+	if got, want := status.Convert(err).Message(), "pool size 1, 1 clients waiting"; !strings.Contains(got, want) {
+		t.Errorf("Got message %q, want it to contain %q", got, want)
+	}
+
+	// The client left the queue.
+	for server.lessor.Stats().Waiting != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMaxWait(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		opts      Options
+		requested *durationpb.Duration
+		want      time.Duration
+		wantErr   bool
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:      "requested without a cap",
+			requested: durationpb.New(time.Hour),
+			want:      time.Hour,
+		},
+		{
+			name:      "capped",
+			opts:      Options{MaxWait: time.Minute},
+			requested: durationpb.New(time.Hour),
+			want:      time.Minute,
+		},
+		{
+			name: "default is the cap",
+			opts: Options{MaxWait: time.Hour},
+			want: time.Hour,
+		},
+		{
+			name:      "negative",
+			requested: durationpb.New(-time.Minute),
+			wantErr:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New(simulated.NewClock(time.Now()), tc.opts)
+			got, err := s.maxWait(&pb.GetDatabaseInstanceRequest{MaxWait: tc.requested})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Got error %v, want error: %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("Got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 // The test client receives responses from the server in its Run() method and
 // exposes the messages it receives via the channels.
 type testClient struct {
@@ -343,6 +777,21 @@ func (c *testClient) AssertError(desc string, expErr error, t *testing.T) {
 	}
 }
 
+// Gets the error that ended the stream.
+func (c *testClient) GetError(desc string, t *testing.T) (err error) {
+	select {
+	case resp, ok := <-c.respCh:
+		if ok {
+			t.Fatalf("%v: got response, want none: %v", desc, resp)
+		}
+		err = <-c.errCh // the stream ended
+	case err = <-c.errCh:
+	case <-time.After(expMessageDur):
+		t.Fatalf("%v: Got no error, want error", desc)
+	}
+	return
+}
+
 // Wait for Run() to finish. Asserts no errors or responses were received.
 func (c *testClient) Wait(t *testing.T) {
 	select {
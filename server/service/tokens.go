@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/karagog/clock-go"
+	"github.com/karagog/db-provider/server/lessor"
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// This file implements the unary lease API, in which clients hold leases with a token.
+
+// How long a token lease lasts without renewal, unless configured otherwise.
+const defaultHeartbeatTTL = time.Minute
+
+// A lease held with a token, which the holder keeps alive by renewing it.
+type tokenLease struct {
+	id        string         // const
+	lessor    *lessor.Lessor // const
+	lease     lessor.Lease   // const
+	heartbeat time.Duration  // const
+	deadline  time.Time      // const; the lease can't be renewed past this, unless it's zero
+
+	expireTime time.Time     // guarded by the service's mutex
+	retain     bool          // whether to retain the databases; set before released is closed
+	released   chan struct{} // closed when the client releases the lease
+	done       chan struct{} // closed once the lease has been returned to the lessor
+
+	retained []*pb.RetainedDatabase // set before done is closed
+}
+
+// Returns when the lease expires if it's renewed now.
+func (tl *tokenLease) nextExpiry(now time.Time) time.Time {
+	t := now.Add(tl.heartbeat)
+	if !tl.deadline.IsZero() && t.After(tl.deadline) {
+		t = tl.deadline
+	}
+	return t
+}
+
+func (s *Service) AcquireLease(ctx context.Context, req *pb.AcquireLeaseRequest) (*pb.AcquireLeaseResponse, error) {
+	r := req.Request
+	if r == nil {
+		r = &pb.GetDatabaseInstanceRequest{}
+	}
+	ttl, err := s.leaseDuration(r)
+	if err != nil {
+		return nil, err
+	}
+	maxWait, err := s.maxWait(r)
+	if err != nil {
+		return nil, err
+	}
+	heartbeat, err := s.heartbeatTTL(req)
+	if err != nil {
+		return nil, err
+	}
+	les, err := s.pool(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	glog.V(1).Infof("Token lease requested from pool %q by %s", les.Name(), lessor.DescribeClient(r.ClientInfo))
+
+	// Give up if we wait too long.
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timedOut := make(chan struct{})
+	var st lessor.Stats // the pool's stats when we gave up
+	if maxWait > 0 {
+		tmr := s.clock.NewTimer(maxWait)
+		defer tmr.Stop()
+		go func() {
+			select {
+			case <-tmr.C():
+				st = les.Stats()
+				close(timedOut)
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}()
+	}
+	lease, err := les.Request(leaseOptions(ctx, r)).Wait(waitCtx)
+	if err != nil {
+		select {
+		case <-timedOut:
+			return nil, waitExhausted(les.Name(), maxWait, st)
+		default:
+		}
+		return nil, leaseStatus(err)
+	}
+
+	now := s.clock.Now()
+	tl := &tokenLease{
+		id:        les.Info(lease).ID,
+		lessor:    les,
+		lease:     lease,
+		heartbeat: heartbeat,
+		retain:    r.RetainOnRelease,
+		released:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if ttl > 0 {
+		tl.deadline = now.Add(ttl)
+	}
+	tl.expireTime = tl.nextExpiry(now)
+	token := newToken()
+	s.mu.Lock()
+	s.tokens[token] = tl
+	s.mu.Unlock()
+	go s.watchToken(token, tl, s.clock.NewTimer(tl.expireTime.Sub(now)))
+
+	return &pb.AcquireLeaseResponse{
+		Token:           token,
+		LeaseId:         tl.id,
+		ConnectionInfos: les.ConnectionInfos(lease),
+		ExpireTime:      timestamppb.New(tl.expireTime),
+	}, nil
+}
+
+func (s *Service) RenewLease(ctx context.Context, req *pb.RenewLeaseRequest) (*pb.RenewLeaseResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tl, ok := s.tokens[req.Token]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such lease, it may have expired")
+	}
+	tl.expireTime = tl.nextExpiry(s.clock.Now())
+	return &pb.RenewLeaseResponse{
+		ExpireTime:   timestamppb.New(tl.expireTime),
+		ShuttingDown: s.draining(),
+	}, nil
+}
+
+func (s *Service) ReleaseLease(ctx context.Context, req *pb.ReleaseLeaseRequest) (*pb.ReleaseLeaseResponse, error) {
+	tl := s.dropToken(req.Token)
+	if tl == nil {
+		return nil, status.Error(codes.NotFound, "no such lease, it may have expired")
+	}
+	if req.Retain {
+		tl.retain = true
+	}
+	close(tl.released)
+	<-tl.done
+	return &pb.ReleaseLeaseResponse{Retained: tl.retained}, nil
+}
+
+// Returns the lease to the lessor when the client releases it, or it expires
+// or is revoked. The timer fires when the lease is due to expire, unless it
+// has been renewed in the meantime.
+func (s *Service) watchToken(token string, tl *tokenLease, tmr clock.Timer) {
+	defer close(tl.done)
+	defer func() { tl.retained = release(tl.lessor, tl.lease, tl.retain) }()
+	defer tmr.Stop()
+	revoked := tl.lessor.Revoked(tl.lease)
+	for {
+		select {
+		case <-tl.released:
+			glog.V(2).Infof("Lease %s was released", tl.id)
+			return
+		case <-revoked:
+			glog.Warningf("Lease %s was revoked, reclaiming it", tl.id)
+			s.dropToken(token)
+			return
+		case <-tmr.C():
+			now := s.clock.Now()
+			s.mu.Lock()
+			expireTime := tl.expireTime
+			s.mu.Unlock()
+			if now.Before(expireTime) {
+				tmr.Reset(expireTime.Sub(now)) // it was renewed
+				break
+			}
+			glog.Warningf("Lease %s expired without renewal, reclaiming it", tl.id)
+			s.dropToken(token)
+			return
+		}
+	}
+}
+
+// Forgets the token and returns its lease, or nil if there was none.
+func (s *Service) dropToken(token string) *tokenLease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tl := s.tokens[token]
+	delete(s.tokens, token)
+	return tl
+}
+
+// A lease held on a GetDatabaseInstance stream, whose token only lets the
+// holder take checkpoints.
+type streamLease struct {
+	lessor *lessor.Lessor
+	lease  lessor.Lease
+}
+
+// Forgets the token of a lease held on a stream.
+func (s *Service) dropStream(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, token)
+}
+
+// Returns the active lease that the token was given for, and the lessor that
+// granted it. Unlike the lease ID, which anyone can see, the token proves
+// that the client holds the lease.
+func (s *Service) leaseByToken(token string) (*lessor.Lessor, lessor.Lease, error) {
+	if token == "" {
+		return nil, nil, status.Error(codes.InvalidArgument, "missing token")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tl, ok := s.tokens[token]; ok {
+		return tl.lessor, tl.lease, nil
+	}
+	if sl, ok := s.streams[token]; ok {
+		return sl.lessor, sl.lease, nil
+	}
+	return nil, nil, status.Error(codes.NotFound, "no such lease, it may have ended")
+}
+
+// Returns how long the client's lease lasts without renewal.
+func (s *Service) heartbeatTTL(req *pb.AcquireLeaseRequest) (time.Duration, error) {
+	if req.HeartbeatTtl == nil {
+		return s.opts.HeartbeatTTL, nil
+	}
+	if err := req.HeartbeatTtl.CheckValid(); err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid heartbeat TTL: %v", err)
+	}
+	ttl := req.HeartbeatTtl.AsDuration()
+	if ttl <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "heartbeat TTL must be positive, got %v", ttl)
+	}
+	return ttl, nil
+}
+
+// Returns a new random token, which is hard to guess.
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/karagog/db-provider/server/proto"
+)
+
+// Returns a client for the server.
+func dialServer(server *serverCtl, t *testing.T) pb.IntegrationTestClient {
+	conn, err := grpc.Dial(server.serviceAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewIntegrationTestClient(conn)
+}
+
+func TestAcquireAndReleaseLease(t *testing.T) {
+	server, stop := startServer(t)
+	server.service.SetLessors(server.lessor)
+	defer stop()
+	cli := dialServer(server, t)
+	ctx := context.Background()
+
+	resp, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(resp.ConnectionInfos), 1; got != want {
+		t.Fatalf("Got %d connection infos, want %d", got, want)
+	}
+	if got, want := resp.ExpireTime.AsTime(), server.clock.Now().Add(defaultHeartbeatTTL); !got.Equal(want) {
+		t.Errorf("Got expire time %v, want %v", got, want)
+	}
+
+	// Streaming clients share the pool, so they have to wait.
+	c := doGetDatabaseInstance(server.serviceAddr, t)
+	go c.Run()
+	if err := c.stream.Send(&pb.GetDatabaseInstanceRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	c.GetResponse("after first message", t)
+	c.AssertNoResponse("waiting for the token lease", t)
+
+	// Once the lease is released, the database goes to the streaming client.
+	if _, err := cli.ReleaseLease(ctx, &pb.ReleaseLeaseRequest{Token: resp.Token}); err != nil {
+		t.Fatal(err)
+	}
+	if resp := c.GetResponse("lease available", t); resp.ConnectionInfo == nil {
+		t.Fatal("Got nil connection info, want info")
+	}
+
+	// The token is no longer valid.
+	_, err = cli.ReleaseLease(ctx, &pb.ReleaseLeaseRequest{Token: resp.Token})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+}
+
+func TestTokenLeaseExpires(t *testing.T) {
+	server, stop := startServerWithOptions(t, Options{MaxLeaseDuration: 90 * time.Second})
+	server.service.SetLessors(server.lessor)
+	defer stop()
+	cli := dialServer(server, t)
+	ctx := context.Background()
+	start := server.clock.Now()
+
+	resp, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{HeartbeatTtl: durationpb.New(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	renew := func(want time.Time) {
+		t.Helper()
+		resp, err := cli.RenewLease(ctx, &pb.RenewLeaseRequest{Token: resp.Token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.ExpireTime.AsTime(); !got.Equal(want) {
+			t.Fatalf("Got expire time %v, want %v", got, want)
+		}
+	}
+
+	// Renewals extend the lease, but not beyond its maximum duration.
+	server.clock.Advance(20 * time.Second)
+	renew(start.Add(80 * time.Second))
+	server.clock.Advance(40 * time.Second) // past the first expiry
+	renew(start.Add(90 * time.Second))
+	if got, want := server.lessor.Stats().Leased, 1; got != want {
+		t.Fatalf("Got %d leased, want %d", got, want)
+	}
+
+	// Without renewals the lease expires, and the database goes back to the pool.
+	server.clock.Advance(30 * time.Second)
+	for server.lessor.Stats().Leased != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = cli.RenewLease(ctx, &pb.RenewLeaseRequest{Token: resp.Token})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+}
+
+func TestAcquireLeaseMaxWait(t *testing.T) {
+	server, stop := startServerWithOptions(t, Options{MaxWait: time.Minute})
+	server.service.SetLessors(server.lessor)
+	defer stop()
+	cli := dialServer(server, t)
+	ctx := context.Background()
+
+	// Hold the only database for longer than the next client will wait.
+	if _, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{HeartbeatTtl: durationpb.New(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := cli.AcquireLease(ctx, &pb.AcquireLeaseRequest{})
+		errCh <- err
+	}()
+	for server.lessor.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	server.clock.Advance(time.Minute)
+	if got, want := status.Code(<-errCh), codes.ResourceExhausted; got != want {
+		t.Fatalf("Got code %v, want %v", got, want)
+	}
+}